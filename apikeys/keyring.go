@@ -0,0 +1,410 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"api/ratelimit"
+)
+
+// Errors returned for keys that cannot be used.
+var (
+	ErrUnknownKey = errors.New("unknown API key")
+	ErrRevoked    = errors.New("API key revoked")
+)
+
+// InputError is returned for a key name or tier that cannot be used, as
+// opposed to a failure to read or write the keys file.
+type InputError string
+
+func (e InputError) Error() string { return string(e) }
+
+// usageDays is how many days of usage are kept per key.
+const usageDays = 31
+
+// File is the layout of the keys file. Tiers replaces DefaultTiers when set.
+type File struct {
+	Tiers map[string]Tier `json:"tiers,omitempty"`
+	Keys  []Key           `json:"keys"`
+}
+
+// Grant is what a key entitles its client to.
+type Grant struct {
+	Key   Key
+	Limit ratelimit.Limit
+	Daily int
+}
+
+// Keyring holds the keys of a keys file and counts their usage per day. The
+// usage is kept next to the keys file, in a file of the same name ending in
+// ".usage.json", and written out by Watch.
+type Keyring struct {
+	path      string
+	usagePath string
+
+	mu      sync.Mutex
+	file    File
+	tiers   map[string]Tier
+	limits  map[string]ratelimit.Limit
+	byHash  map[string]int
+	modTime time.Time
+	size    int64
+
+	// usage counts the requests of each key ID per day.
+	usage map[string]map[string]int
+	dirty bool
+}
+
+// Open loads the keys file at path. A missing file is an empty keyring,
+// created on the first key.
+func Open(path string) (*Keyring, error) {
+	k := &Keyring{
+		path:      path,
+		usagePath: strings.TrimSuffix(path, filepath.Ext(path)) + ".usage.json",
+		usage:     make(map[string]map[string]int),
+	}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(k.usagePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &k.usage); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", k.usagePath, err)
+		}
+	}
+	return k, nil
+}
+
+// Path returns the keys file the keyring is stored in.
+func (k *Keyring) Path() string { return k.path }
+
+// load reads the keys file and replaces the keys held once it checks out.
+// The caller must hold k.mu, except from Open.
+func (k *Keyring) load() error {
+	var file File
+	var modTime time.Time
+	var size int64
+
+	info, err := os.Stat(k.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		data, err := os.ReadFile(k.path)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("invalid %s: %v", k.path, err)
+		}
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	tiers := file.Tiers
+	if len(tiers) == 0 {
+		tiers = DefaultTiers
+	}
+	limits := make(map[string]ratelimit.Limit, len(tiers))
+	for name, tier := range tiers {
+		limit, err := tier.Limit()
+		if err != nil {
+			return fmt.Errorf("invalid %s: tier %q: %v", k.path, name, err)
+		}
+		limits[name] = limit
+	}
+
+	byHash := make(map[string]int, len(file.Keys))
+	ids := make(map[string]bool, len(file.Keys))
+	for i, key := range file.Keys {
+		_, hasTier := tiers[key.Tier]
+		switch {
+		case key.ID == "" || key.Hash == "":
+			return fmt.Errorf("invalid %s: keys[%d] needs an id and a hash", k.path, i)
+		case ids[key.ID]:
+			return fmt.Errorf("invalid %s: key %s is listed twice", k.path, key.ID)
+		case !hasTier:
+			return fmt.Errorf("invalid %s: key %s has unknown tier %q", k.path, key.ID, key.Tier)
+		}
+		ids[key.ID] = true
+		byHash[key.Hash] = i
+	}
+
+	k.file, k.tiers, k.limits, k.byHash = file, tiers, limits, byHash
+	k.modTime, k.size = modTime, size
+	return nil
+}
+
+// refresh reloads the keys file if it changed since it was last read, so
+// edits made by another process are not lost. The caller must hold k.mu.
+func (k *Keyring) refresh() error {
+	info, err := os.Stat(k.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if k.modTime.IsZero() {
+			return nil
+		}
+	} else if err != nil {
+		return err
+	} else if info.ModTime().Equal(k.modTime) && info.Size() == k.size {
+		return nil
+	}
+	return k.load()
+}
+
+// save writes the keys file, through a temporary file so readers never see
+// it half written. The caller must hold k.mu.
+func (k *Keyring) save() error {
+	data, err := json.MarshalIndent(k.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(k.path, append(data, '\n')); err != nil {
+		return err
+	}
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return err
+	}
+	k.modTime, k.size = info.ModTime(), info.Size()
+	return nil
+}
+
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Reload reads the keys file again, keeping the current keys if it fails.
+func (k *Keyring) Reload() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.load()
+}
+
+// Lookup returns the grant of the key whose token is token.
+func (k *Keyring) Lookup(token string) (Grant, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	i, ok := k.byHash[hash(token)]
+	if !ok {
+		return Grant{}, ErrUnknownKey
+	}
+	key := k.file.Keys[i]
+	if key.Revoked != nil {
+		return Grant{}, ErrRevoked
+	}
+	return Grant{Key: key, Limit: k.limits[key.Tier], Daily: k.tiers[key.Tier].Daily}, nil
+}
+
+// Keys returns every key, revoked ones included, oldest first.
+func (k *Keyring) Keys() []Key {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return slices.Clone(k.file.Keys)
+}
+
+// Key returns the key with the given ID.
+func (k *Keyring) Key(id string) (Key, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i := slices.IndexFunc(k.file.Keys, func(key Key) bool { return key.ID == id })
+	if i < 0 {
+		return Key{}, false
+	}
+	return k.file.Keys[i], true
+}
+
+// Tiers returns the tiers keys can be issued in.
+func (k *Keyring) Tiers() map[string]Tier {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return maps.Clone(k.tiers)
+}
+
+// Create issues a key named name in tier and saves it. It returns the token
+// to hand to the client, which cannot be recovered afterwards. A bad name or
+// tier is an InputError.
+func (k *Keyring) Create(name, tier string, now time.Time) (string, Key, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.refresh(); err != nil {
+		return "", Key{}, err
+	}
+
+	tier = strings.ToLower(strings.TrimSpace(tier))
+	if _, ok := k.tiers[tier]; !ok {
+		tiers := slices.Sorted(maps.Keys(k.tiers))
+		return "", Key{}, InputError(fmt.Sprintf("unknown tier %q, expected one of %s", tier, strings.Join(tiers, ", ")))
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", Key{}, InputError("a key needs a name")
+	}
+
+	token, h, err := newToken()
+	for err == nil && slices.ContainsFunc(k.file.Keys, func(key Key) bool { return key.ID == h[:12] }) {
+		token, h, err = newToken()
+	}
+	if err != nil {
+		return "", Key{}, err
+	}
+	key := Key{ID: h[:12], Name: strings.TrimSpace(name), Tier: tier, Hash: h, Created: now.UTC()}
+	k.file.Keys = append(k.file.Keys, key)
+	if err := k.save(); err != nil {
+		k.file.Keys = k.file.Keys[:len(k.file.Keys)-1]
+		return "", Key{}, err
+	}
+	k.byHash[h] = len(k.file.Keys) - 1
+	return token, key, nil
+}
+
+// Revoke revokes the key with the given ID and saves it. Revoking a revoked
+// key keeps its original revocation time.
+func (k *Keyring) Revoke(id string, now time.Time) (Key, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.refresh(); err != nil {
+		return Key{}, err
+	}
+
+	i := slices.IndexFunc(k.file.Keys, func(key Key) bool { return key.ID == id })
+	if i < 0 {
+		return Key{}, ErrUnknownKey
+	}
+	key := &k.file.Keys[i]
+	if key.Revoked != nil {
+		return *key, nil
+	}
+	revoked := now.UTC()
+	key.Revoked = &revoked
+	if err := k.save(); err != nil {
+		key.Revoked = nil
+		return Key{}, err
+	}
+	return *key, nil
+}
+
+// Used returns how many requests the key with the given ID made on the UTC
+// day of now.
+func (k *Keyring) Used(id string, now time.Time) int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.usage[id][Day(now)]
+}
+
+// Consume counts a request of the key with the given ID on the UTC day of now
+// unless the key already made daily requests that day, a daily of 0 being
+// unlimited. It returns the day's count and whether the request was counted.
+// Checking and counting happen under one lock, so concurrent requests cannot
+// overrun the quota.
+func (k *Keyring) Consume(id string, now time.Time, daily int) (int, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	day := Day(now)
+	if used := k.usage[id][day]; daily > 0 && used >= daily {
+		return used, false
+	}
+	days := k.usage[id]
+	if days == nil {
+		days = make(map[string]int)
+		k.usage[id] = days
+	}
+	days[day]++
+	k.dirty = true
+	return days[day], true
+}
+
+// Usage returns the requests of the key with the given ID per UTC day.
+func (k *Keyring) Usage(id string) map[string]int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return maps.Clone(k.usage[id])
+}
+
+// FlushUsage writes the usage file, dropping days older than usageDays.
+func (k *Keyring) FlushUsage(now time.Time) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.dirty {
+		return nil
+	}
+
+	oldest := Day(now.AddDate(0, 0, -usageDays))
+	for id, days := range k.usage {
+		maps.DeleteFunc(days, func(day string, _ int) bool { return day < oldest })
+		if len(days) == 0 {
+			delete(k.usage, id)
+		}
+	}
+	data, err := json.MarshalIndent(k.usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(k.usagePath, append(data, '\n')); err != nil {
+		return err
+	}
+	k.dirty = false
+	return nil
+}
+
+// Watch reloads the keys file when it changes and writes out the usage every
+// interval, until ctx is cancelled. A keys file that fails to load keeps the
+// previous keys in use.
+func (k *Keyring) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var failed string
+	for {
+		select {
+		case <-ctx.Done():
+			if err := k.FlushUsage(time.Now()); err != nil {
+				log.Printf("Error writing API key usage: %v", err)
+			}
+			return
+		case <-ticker.C:
+		}
+
+		k.mu.Lock()
+		err := k.refresh()
+		k.mu.Unlock()
+		// A broken file is reported once rather than on every tick.
+		if err != nil && err.Error() != failed {
+			log.Printf("Error reloading API keys, keeping previous keys: %v", err)
+		}
+		failed = ""
+		if err != nil {
+			failed = err.Error()
+		}
+		if err := k.FlushUsage(time.Now()); err != nil {
+			log.Printf("Error writing API key usage: %v", err)
+		}
+	}
+}
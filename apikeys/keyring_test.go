@@ -0,0 +1,68 @@
+package apikeys
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, time.May, 22, 12, 0, 0, 0, time.UTC)
+
+func TestConsume(t *testing.T) {
+	k, err := Open(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var counted atomic.Int32
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := k.Consume("key", now, 10); ok {
+				counted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if counted.Load() != 10 || k.Used("key", now) != 10 {
+		t.Errorf("100 concurrent requests counted %d, used %d, want the quota of 10", counted.Load(), k.Used("key", now))
+	}
+
+	if used, ok := k.Consume("key", now.Add(24*time.Hour), 10); !ok || used != 1 {
+		t.Errorf("Consume() the next day = %d, %v, want a fresh quota", used, ok)
+	}
+	if used, ok := k.Consume("key", now, 0); !ok || used != 11 {
+		t.Errorf("Consume() without a quota = %d, %v, want it counted", used, ok)
+	}
+}
+
+func TestWatchFlushesOnCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	k, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+	k.Consume("key", time.Now(), 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		k.Watch(ctx, time.Hour)
+	}()
+	cancel()
+	<-done
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() again = %v", err)
+	}
+	if used := reopened.Used("key", time.Now()); used != 1 {
+		t.Errorf("usage after the watcher stopped = %d, want the request written out", used)
+	}
+}
@@ -0,0 +1,94 @@
+// Package apikeys issues the API keys clients send in the X-API-Key header.
+// Each key belongs to a Tier setting its rate limit and daily quota. Keys are
+// kept in a JSON file that the server watches, so keys created or revoked
+// there, by the admin routes or by the keys command, apply without a restart.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"api/ratelimit"
+)
+
+// Header is the request header carrying an API key.
+const Header = "X-API-Key"
+
+// tokenPrefix marks the API keys issued by this server.
+const tokenPrefix = "rr_"
+
+// Key is an issued API key. Only a hash of its token is kept; the token
+// itself is shown once, when the key is created.
+type Key struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name"`
+	Tier    string     `json:"tier"`
+	Hash    string     `json:"hash"`
+	Created time.Time  `json:"created"`
+	Revoked *time.Time `json:"revoked,omitempty"`
+}
+
+// Tier is the rate limit and the number of requests per UTC day that keys of
+// the tier get. A Daily of 0 leaves the number of requests per day unlimited.
+type Tier struct {
+	Requests int    `json:"requests"`
+	Window   string `json:"window"`
+	Burst    int    `json:"burst,omitempty"`
+	Daily    int    `json:"daily,omitempty"`
+}
+
+// DefaultTiers are the tiers available when the keys file defines none.
+var DefaultTiers = map[string]Tier{
+	"free":      {Requests: 300, Window: "1m", Daily: 20000},
+	"standard":  {Requests: 1200, Window: "1m", Daily: 200000},
+	"unlimited": {Requests: 6000, Window: "1m"},
+}
+
+// Limit returns the rate limit of the tier. Burst defaults to Requests.
+func (t Tier) Limit() (ratelimit.Limit, error) {
+	window, err := time.ParseDuration(t.Window)
+	if err != nil || window <= 0 {
+		return ratelimit.Limit{}, fmt.Errorf("window %q is not a positive duration", t.Window)
+	}
+	if t.Requests <= 0 {
+		return ratelimit.Limit{}, fmt.Errorf("requests must be positive")
+	}
+	if t.Burst < 0 || t.Daily < 0 {
+		return ratelimit.Limit{}, fmt.Errorf("burst and daily cannot be negative")
+	}
+	burst := t.Burst
+	if burst == 0 {
+		burst = t.Requests
+	}
+	return ratelimit.Limit{Requests: t.Requests, Window: window, Burst: burst}, nil
+}
+
+// hash returns the hex SHA-256 of token, under which its key is stored.
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns a random token and its hash.
+func newToken() (string, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := tokenPrefix + hex.EncodeToString(b)
+	return token, hash(token), nil
+}
+
+// Day returns the UTC day usage at t is counted under, as YYYY-MM-DD.
+func Day(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// NextDay returns the start of the UTC day after t, when daily quotas reset.
+func NextDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,37 @@
+// Package assets serves the game images: character portraits, weapon icons,
+// attribute icons and emojis. They come from a Source, either a Proxy
+// fetching them from the CDN and keeping them in a DiskCache, or an FS
+// reading them from a local directory or from the binary. Images resizes and
+// converts them on request.
+package assets
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned for assets the source does not have.
+var ErrNotFound = errors.New("asset not found")
+
+// Asset is an image and the headers it is served with.
+type Asset struct {
+	Body         []byte    `json:"-"`
+	ContentType  string    `json:"contentType"`
+	CacheControl string    `json:"cacheControl,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+// Source provides the assets by path, such as "characters/icons/rover.png".
+// See the layout functions for the paths.
+type Source interface {
+	Get(ctx context.Context, path string) (*Asset, error)
+}
+
+// Linker is implemented by sources whose assets can be linked to directly,
+// rather than through the API.
+type Linker interface {
+	URL(path string) string
+}
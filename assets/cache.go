@@ -0,0 +1,225 @@
+package assets
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheSuffix ends the names of the files the cache stores assets in.
+const cacheSuffix = ".asset"
+
+// DiskCache keeps assets in a directory, removing the least recently used
+// once they take more than a given number of bytes. Each asset is a file
+// holding its headers as a line of JSON followed by its body.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	name string
+	size int64
+}
+
+// OpenDiskCache opens the cache in dir, creating it if needed. Assets already
+// in dir are kept, ordered by when they were last used.
+func OpenDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &DiskCache{dir: dir, maxBytes: maxBytes, lru: list.New(), entries: make(map[string]*list.Element)}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type found struct {
+		name string
+		info os.FileInfo
+	}
+	var existing []found
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if isTempFile(file.Name()) {
+			// Left behind by a write that did not finish.
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		if !isCacheFile(file.Name()) {
+			// Not the cache's; the directory may be shared by mistake.
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		existing = append(existing, found{file.Name(), info})
+	}
+	slices.SortFunc(existing, func(a, b found) int { return b.info.ModTime().Compare(a.info.ModTime()) })
+	for _, f := range existing {
+		d.entries[f.name] = d.lru.PushBack(&cacheEntry{name: f.name, size: f.info.Size()})
+		d.size += f.info.Size()
+	}
+
+	d.mu.Lock()
+	d.evict()
+	d.mu.Unlock()
+	return d, nil
+}
+
+func cacheName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:]) + cacheSuffix
+}
+
+// isCacheFile reports whether name is a file the cache stores an asset in,
+// a hex SHA-256 followed by cacheSuffix.
+func isCacheFile(name string) bool {
+	hash, ok := strings.CutSuffix(name, cacheSuffix)
+	return ok && isHash(hash)
+}
+
+// isTempFile reports whether name is a temporary file Put writes an asset
+// to before renaming it, the asset's name followed by a dot and a random
+// suffix.
+func isTempFile(name string) bool {
+	hash, rest, ok := strings.Cut(name, cacheSuffix+".")
+	return ok && rest != "" && isHash(hash)
+}
+
+func isHash(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Get returns the asset stored under key.
+func (d *DiskCache) Get(key string) (*Asset, bool) {
+	name := cacheName(key)
+	d.mu.Lock()
+	element, ok := d.entries[name]
+	if ok {
+		d.lru.MoveToFront(element)
+	}
+	d.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	path := filepath.Join(d.dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		d.Remove(key)
+		return nil, false
+	}
+	header, body, ok := bytes.Cut(data, []byte("\n"))
+	asset := &Asset{Body: body}
+	if !ok || json.Unmarshal(header, asset) != nil {
+		d.Remove(key)
+		return nil, false
+	}
+	// The modification time records the last use across restarts.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return asset, true
+}
+
+// Put stores asset under key. Assets larger than the whole cache are not
+// stored.
+func (d *DiskCache) Put(key string, asset *Asset) error {
+	header, err := json.Marshal(asset)
+	if err != nil {
+		return err
+	}
+	size := int64(len(header) + 1 + len(asset.Body))
+	if size > d.maxBytes {
+		return nil
+	}
+
+	name := cacheName(key)
+	tmp, err := os.CreateTemp(d.dir, name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	w.Write(header)
+	w.WriteByte('\n')
+	w.Write(asset.Body)
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := os.Rename(tmp.Name(), filepath.Join(d.dir, name)); err != nil {
+		return err
+	}
+	if element, ok := d.entries[name]; ok {
+		entry := element.Value.(*cacheEntry)
+		d.size += size - entry.size
+		entry.size = size
+		d.lru.MoveToFront(element)
+	} else {
+		d.entries[name] = d.lru.PushFront(&cacheEntry{name: name, size: size})
+		d.size += size
+	}
+	d.evict()
+	return nil
+}
+
+// Remove deletes the asset stored under key.
+func (d *DiskCache) Remove(key string) {
+	name := cacheName(key)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if element, ok := d.entries[name]; ok {
+		d.remove(element)
+	}
+}
+
+// Size returns the bytes the cached assets take.
+func (d *DiskCache) Size() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size
+}
+
+// evict removes the least recently used assets until the cache fits. The
+// caller must hold d.mu.
+func (d *DiskCache) evict() {
+	for d.size > d.maxBytes {
+		d.remove(d.lru.Back())
+	}
+}
+
+func (d *DiskCache) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	d.lru.Remove(element)
+	delete(d.entries, entry.name)
+	d.size -= entry.size
+	os.Remove(filepath.Join(d.dir, entry.name))
+}
@@ -0,0 +1,86 @@
+package assets
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	asset := &Asset{Body: make([]byte, 100), ContentType: "image/png", Fetched: time.Date(2024, time.May, 22, 0, 0, 0, 0, time.UTC)}
+	header, _ := json.Marshal(asset)
+	size := int64(len(header) + 1 + len(asset.Body))
+
+	dir := t.TempDir()
+	d, err := OpenDiskCache(dir, 3*size)
+	if err != nil {
+		t.Fatalf("OpenDiskCache() = %v", err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := d.Put(key, asset); err != nil {
+			t.Fatalf("Put(%s) = %v", key, err)
+		}
+	}
+	if d.Size() != 3*size {
+		t.Errorf("Size() = %d, want %d", d.Size(), 3*size)
+	}
+
+	// Using a makes b the least recently used.
+	if _, ok := d.Get("a"); !ok {
+		t.Fatalf("Get(a) missed")
+	}
+	if err := d.Put("d", asset); err != nil {
+		t.Fatalf("Put(d) = %v", err)
+	}
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if _, ok := d.Get(key); ok != want {
+			t.Errorf("Get(%s) = %v, want %v", key, ok, want)
+		}
+	}
+	if d.Size() != 3*size {
+		t.Errorf("Size() = %d after evicting, want %d", d.Size(), 3*size)
+	}
+
+	// Assets larger than the cache are not stored.
+	if err := d.Put("big", &Asset{Body: make([]byte, 4*size)}); err != nil {
+		t.Fatalf("Put(big) = %v", err)
+	}
+	if _, ok := d.Get("big"); ok {
+		t.Errorf("Get(big) found an asset larger than the cache")
+	}
+
+	// Reopening keeps what fits.
+	reopened, err := OpenDiskCache(dir, 3*size)
+	if err != nil {
+		t.Fatalf("OpenDiskCache() again = %v", err)
+	}
+	if got, ok := reopened.Get("d"); !ok || len(got.Body) != 100 || got.ContentType != "image/png" {
+		t.Errorf("Get(d) after reopening = %+v, %v", got, ok)
+	}
+}
+
+func TestOpenDiskCacheKeepsForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	leftover := cacheName("a") + ".123456"
+	for _, name := range []string{"notes.txt", "photo.asset", leftover} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := OpenDiskCache(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("OpenDiskCache() = %v", err)
+	}
+	for name, want := range map[string]bool{"notes.txt": true, "photo.asset": true, leftover: false} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v after opening, want %v", name, exists, want)
+		}
+	}
+	if d.Size() != 0 {
+		t.Errorf("Size() = %d, want files that are not the cache's left out", d.Size())
+	}
+}
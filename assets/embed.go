@@ -0,0 +1,16 @@
+//go:build embedassets
+
+package assets
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:files
+var embedded embed.FS
+
+// Embedded returns the assets built into the binary from assets/files.
+func Embedded() (fs.FS, error) {
+	return fs.Sub(embedded, "files")
+}
@@ -0,0 +1,79 @@
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FS is a Source reading assets from a filesystem laid out like the CDN, such
+// as a local directory or the embedded assets.
+type FS struct {
+	fsys fs.FS
+
+	// CacheControl is sent with every asset.
+	CacheControl string
+
+	// etags remembers the ETag of each file by its size and modification
+	// time, so files are only hashed again when they change.
+	etags sync.Map
+}
+
+type fileETag struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// NewFS returns a Source reading from fsys.
+func NewFS(fsys fs.FS) *FS {
+	return &FS{fsys: fsys, CacheControl: "public, max-age=3600"}
+}
+
+func (f *FS) Get(_ context.Context, path string) (*Asset, error) {
+	if !fs.ValidPath(path) {
+		return nil, ErrNotFound
+	}
+	info, err := fs.Stat(f.fsys, path)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	body, err := fs.ReadFile(f.fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	asset := &Asset{
+		Body:         body,
+		ContentType:  ContentType(path, body),
+		CacheControl: f.CacheControl,
+		ETag:         f.etag(path, info, body),
+		Fetched:      time.Now(),
+	}
+	// Embedded files have no modification time.
+	if !info.ModTime().IsZero() {
+		asset.LastModified = info.ModTime().UTC().Format(http.TimeFormat)
+	}
+	return asset, nil
+}
+
+func (f *FS) etag(path string, info fs.FileInfo, body []byte) string {
+	if known, ok := f.etags.Load(path); ok {
+		known := known.(fileETag)
+		if known.size == info.Size() && known.modTime.Equal(info.ModTime()) {
+			return known.etag
+		}
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	f.etags.Store(path, fileETag{size: info.Size(), modTime: info.ModTime(), etag: etag})
+	return etag
+}
@@ -0,0 +1,105 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFSGet(t *testing.T) {
+	modTime := time.Date(2024, 5, 23, 10, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"characters/icons/rover.png": {Data: []byte("\x89PNG\r\n\x1a\nrover"), ModTime: modTime},
+		"weapons/icons/sword.webp":   {Data: []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")},
+	}
+	f := NewFS(fsys)
+
+	asset, err := f.Get(context.Background(), "characters/icons/rover.png")
+	if err != nil {
+		t.Fatalf("Get(rover.png) = %v", err)
+	}
+	if string(asset.Body) != "\x89PNG\r\n\x1a\nrover" {
+		t.Errorf("body = %q", asset.Body)
+	}
+	if asset.ContentType != "image/png" {
+		t.Errorf("content type = %q, want image/png", asset.ContentType)
+	}
+	if asset.CacheControl != f.CacheControl {
+		t.Errorf("cache control = %q, want %q", asset.CacheControl, f.CacheControl)
+	}
+	if want := modTime.Format(http.TimeFormat); asset.LastModified != want {
+		t.Errorf("last modified = %q, want %q", asset.LastModified, want)
+	}
+	if asset.ETag == "" {
+		t.Error("no ETag")
+	}
+
+	// Files without a modification time, like embedded ones, send none.
+	asset, err = f.Get(context.Background(), "weapons/icons/sword.webp")
+	if err != nil {
+		t.Fatalf("Get(sword.webp) = %v", err)
+	}
+	if asset.LastModified != "" {
+		t.Errorf("last modified = %q, want none", asset.LastModified)
+	}
+}
+
+func TestFSGetNotFound(t *testing.T) {
+	fsys := fstest.MapFS{
+		"characters/icons/rover.png": {Data: []byte("rover")},
+		"secret.txt":                 {Data: []byte("secret")},
+	}
+	f := NewFS(fsys)
+
+	tests := []string{
+		"characters/icons/jiyan.png",
+		"characters/icons",
+		"characters",
+		"",
+		// Paths must stay within the filesystem and be clean.
+		"../secret.txt",
+		"characters/../secret.txt",
+		"characters/icons/../../secret.txt",
+		"/secret.txt",
+		"./secret.txt",
+		"characters//icons/rover.png",
+		"characters/icons/rover.png/",
+	}
+	for _, path := range tests {
+		if asset, err := f.Get(context.Background(), path); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, %v, want ErrNotFound", path, asset, err)
+		}
+	}
+}
+
+func TestFSETag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"characters/icons/rover.png": {Data: []byte("rover"), ModTime: time.Unix(1, 0)},
+		"characters/icons/jiyan.png": {Data: []byte("jiyan"), ModTime: time.Unix(1, 0)},
+	}
+	f := NewFS(fsys)
+	get := func(path string) string {
+		t.Helper()
+		asset, err := f.Get(context.Background(), path)
+		if err != nil {
+			t.Fatalf("Get(%q) = %v", path, err)
+		}
+		return asset.ETag
+	}
+
+	rover := get("characters/icons/rover.png")
+	if again := get("characters/icons/rover.png"); again != rover {
+		t.Errorf("ETag changed from %s to %s for the same file", rover, again)
+	}
+	if jiyan := get("characters/icons/jiyan.png"); jiyan == rover {
+		t.Errorf("different files share the ETag %s", rover)
+	}
+
+	fsys["characters/icons/rover.png"] = &fstest.MapFile{Data: []byte("rover v2"), ModTime: time.Unix(2, 0)}
+	if changed := get("characters/icons/rover.png"); changed == rover {
+		t.Errorf("ETag %s kept after the file changed", rover)
+	}
+}
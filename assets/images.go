@@ -0,0 +1,157 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"runtime"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrUnsupported is returned for images that cannot be transformed.
+var ErrUnsupported = errors.New("image cannot be transformed")
+
+// jpegQuality is the quality images converted to JPEG are encoded with.
+const jpegQuality = 85
+
+// Images serves the assets of a Source resized and converted as requested.
+// Transformed images are kept in Cache under their path, the transform and
+// the original's contents, so a changed original is transformed again.
+// Concurrent requests for the same image share one transform, and at most
+// one transform runs per CPU.
+type Images struct {
+	Source Source
+	Cache  *DiskCache
+
+	// MaxDimension is the largest width or height that may be asked for.
+	MaxDimension int
+
+	// MaxPixels is the largest original, in pixels, that is decoded.
+	MaxPixels int
+
+	calls group
+	slots chan struct{}
+}
+
+// NewImages returns Images for source, caching in cache, which may be nil.
+func NewImages(source Source, cache *DiskCache) *Images {
+	return &Images{
+		Source:       source,
+		Cache:        cache,
+		MaxDimension: 1024,
+		MaxPixels:    40_000_000,
+		slots:        make(chan struct{}, runtime.NumCPU()),
+	}
+}
+
+// Get returns the asset at assetPath transformed by t.
+func (im *Images) Get(ctx context.Context, assetPath string, t Transform) (*Asset, error) {
+	original, err := im.Source.Get(ctx, assetPath)
+	if err != nil || t.IsZero() {
+		return original, err
+	}
+
+	sum := sha256.Sum256(original.Body)
+	key := "image:" + assetPath + "?" + t.String() + "#" + hex.EncodeToString(sum[:])
+	if im.Cache != nil {
+		if asset, ok := im.Cache.Get(key); ok {
+			return withHeaders(asset, original), nil
+		}
+	}
+
+	asset, err := im.calls.do(key, func() (*Asset, error) {
+		asset, err := im.transform(original, t)
+		if err != nil || asset == original {
+			return asset, err
+		}
+		etag := sha256.Sum256([]byte(key))
+		asset.ETag = `"` + hex.EncodeToString(etag[:16]) + `"`
+		if im.Cache != nil {
+			if err := im.Cache.Put(key, asset); err != nil {
+				log.Printf("Error caching image %s: %v", key, err)
+			}
+		}
+		return asset, nil
+	})
+	if err != nil || asset == original {
+		return asset, err
+	}
+	return withHeaders(asset, original), nil
+}
+
+// withHeaders returns a copy of transformed with the caching headers of the
+// original it was made from.
+func withHeaders(transformed, original *Asset) *Asset {
+	asset := *transformed
+	asset.CacheControl = original.CacheControl
+	asset.LastModified = original.LastModified
+	return &asset
+}
+
+// transform decodes original and encodes it again at the size and in the
+// format t asks for. original is returned as is when t changes neither.
+func (im *Images) transform(original *Asset, t Transform) (*Asset, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(original.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if _, ok := formats[format]; !ok {
+		format = "png"
+	}
+	if t.Format == "" {
+		t.Format = format
+	}
+	width, height := t.fit(config.Width, config.Height)
+	if width == config.Width && height == config.Height && t.Format == format {
+		return original, nil
+	}
+	if config.Width*config.Height > im.MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d is too large", ErrUnsupported, config.Width, config.Height)
+	}
+
+	im.slots <- struct{}{}
+	defer func() { <-im.slots }()
+
+	img, _, err := image.Decode(bytes.NewReader(original.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if width != config.Width || height != config.Height {
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+		img = scaled
+	}
+
+	var buf bytes.Buffer
+	switch t.Format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "webp":
+		err = encodeWebP(&buf, img)
+	case "jpeg":
+		// JPEG has no transparency, so transparent parts turn white.
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		err = jpeg.Encode(&buf, flat, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Asset{
+		Body:        buf.Bytes(),
+		ContentType: formats[t.Format],
+		Fetched:     time.Now(),
+	}, nil
+}
@@ -0,0 +1,32 @@
+package assets
+
+import "fmt"
+
+// The paths of the assets, shared by every Source. A local directory or
+// embedded filesystem holds the same tree as the CDN:
+//
+//	characters/{portraits,icons,circles,cards}/<name>.png
+//	characters/emojis/<name>/<index>.png
+//	weapons/<type>/<name>.png
+//	attributes/icon/<name>.webp
+
+// CharacterImage returns the path of a character's image of the given kind:
+// portrait, icon, circle or card.
+func CharacterImage(kind, name string) string {
+	return fmt.Sprintf("characters/%ss/%s.png", kind, name)
+}
+
+// CharacterEmoji returns the path of a character's emoji.
+func CharacterEmoji(name string, index int) string {
+	return fmt.Sprintf("characters/emojis/%s/%d.png", name, index)
+}
+
+// WeaponIcon returns the path of a weapon's icon.
+func WeaponIcon(weaponType, name string) string {
+	return fmt.Sprintf("weapons/%s/%s.png", weaponType, name)
+}
+
+// AttributeIcon returns the path of an attribute's icon.
+func AttributeIcon(name string) string {
+	return fmt.Sprintf("attributes/icon/%s.webp", name)
+}
@@ -0,0 +1,14 @@
+//go:build !embedassets
+
+package assets
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Embedded returns the assets built into the binary from assets/files, which
+// are only included when building with -tags embedassets.
+func Embedded() (fs.FS, error) {
+	return nil, errors.New("built without embedded assets, build with -tags embedassets")
+}
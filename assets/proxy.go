@@ -0,0 +1,283 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxAssetSize is the largest body the Proxy accepts from the CDN.
+const MaxAssetSize = 32 << 20
+
+// maxMissing bounds the number of paths remembered as missing.
+const maxMissing = 10000
+
+var errTooLarge = fmt.Errorf("asset is larger than %d bytes", MaxAssetSize)
+
+// Proxy is a Source fetching assets from a CDN. Assets are served from the
+// cache for MaxAge after they were fetched. For StaleFor after that they are
+// still served while they are revalidated in the background, and past it
+// they are revalidated before being served, falling back to the cached copy
+// when the CDN fails. Concurrent requests for an asset share one fetch.
+//
+// BaseURL and Client may point at an httptest.Server.
+type Proxy struct {
+	BaseURL  string
+	Client   *http.Client
+	Cache    *DiskCache
+	MaxAge   time.Duration
+	StaleFor time.Duration
+
+	// MissingFor is how long the CDN answering 404 for a path is trusted.
+	MissingFor time.Duration
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+
+	calls   group
+	mu      sync.Mutex
+	missing map[string]time.Time
+}
+
+// group runs one call at a time for each key, concurrent callers with the
+// same key waiting for it and sharing its result.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done  chan struct{}
+	asset *Asset
+	err   error
+}
+
+func (g *group) do(key string, fn func() (*Asset, error)) (*Asset, error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.asset, c.err
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.asset, c.err = fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+	return c.asset, c.err
+}
+
+// NewProxy returns a Proxy for the CDN at baseURL, caching in cache, which may
+// be nil, with the given upstream timeout.
+func NewProxy(baseURL string, cache *DiskCache, timeout time.Duration) *Proxy {
+	return &Proxy{
+		BaseURL:    baseURL,
+		Client:     &http.Client{Timeout: timeout},
+		Cache:      cache,
+		MaxAge:     time.Hour,
+		StaleFor:   24 * time.Hour,
+		MissingFor: time.Minute,
+	}
+}
+
+func (p *Proxy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *Proxy) Get(ctx context.Context, assetPath string) (*Asset, error) {
+	now := p.now()
+	if p.isMissing(assetPath, now) {
+		return nil, ErrNotFound
+	}
+
+	var cached *Asset
+	if p.Cache != nil {
+		cached, _ = p.Cache.Get(assetPath)
+	}
+	if cached != nil {
+		age := now.Sub(cached.Fetched)
+		if age < p.MaxAge {
+			return cached, nil
+		}
+		if age < p.MaxAge+p.StaleFor {
+			go func() {
+				if _, err := p.fetch(assetPath, cached); err != nil && !errors.Is(err, ErrNotFound) {
+					log.Printf("Error revalidating asset %s: %v", assetPath, err)
+				}
+			}()
+			return cached, nil
+		}
+	}
+
+	asset, err := p.fetch(assetPath, cached)
+	if err != nil && cached != nil && !errors.Is(err, ErrNotFound) {
+		log.Printf("Error fetching asset %s, serving cached copy: %v", assetPath, err)
+		return cached, nil
+	}
+	return asset, err
+}
+
+func (p *Proxy) isMissing(assetPath string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, ok := p.missing[assetPath]
+	if ok && !now.Before(until) {
+		delete(p.missing, assetPath)
+		return false
+	}
+	return ok
+}
+
+// fetch requests assetPath from the CDN, or waits for the request already
+// under way.
+func (p *Proxy) fetch(assetPath string, cached *Asset) (*Asset, error) {
+	return p.calls.do(assetPath, func() (*Asset, error) {
+		asset, err := p.request(assetPath, cached)
+		if errors.Is(err, ErrNotFound) {
+			p.mu.Lock()
+			p.remember(assetPath)
+			p.mu.Unlock()
+		}
+		return asset, err
+	})
+}
+
+// remember records that the CDN does not have assetPath. The caller must
+// hold p.mu.
+func (p *Proxy) remember(assetPath string) {
+	if p.MissingFor <= 0 {
+		return
+	}
+	if p.missing == nil {
+		p.missing = make(map[string]time.Time)
+	}
+	now := p.now()
+	if len(p.missing) >= maxMissing {
+		for missing, until := range p.missing {
+			if !now.Before(until) {
+				delete(p.missing, missing)
+			}
+		}
+		if len(p.missing) >= maxMissing {
+			clear(p.missing)
+		}
+	}
+	p.missing[assetPath] = now.Add(p.MissingFor)
+}
+
+// request fetches assetPath, revalidating cached when given. The fetch is not
+// tied to the client that caused it, as others may be waiting on it; the
+// Client's timeout bounds it instead.
+func (p *Proxy) request(assetPath string, cached *Asset) (*Asset, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, p.URL(assetPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	now := p.now()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		revalidated := *cached
+		revalidated.Fetched = now
+		p.store(assetPath, &revalidated)
+		return &revalidated, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		if p.Cache != nil {
+			p.Cache.Remove(assetPath)
+		}
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("CDN answered %s", resp.Status)
+	}
+
+	body, err := readAll(resp.Body, MaxAssetSize)
+	if err != nil {
+		return nil, err
+	}
+	asset := &Asset{
+		Body:         body,
+		ContentType:  resp.Header.Get("Content-Type"),
+		CacheControl: resp.Header.Get("Cache-Control"),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      now,
+	}
+	if asset.ContentType == "" {
+		asset.ContentType = ContentType(assetPath, body)
+	}
+	p.store(assetPath, asset)
+	return asset, nil
+}
+
+// URL returns the address of assetPath on the CDN.
+func (p *Proxy) URL(assetPath string) string {
+	segments := strings.Split(strings.TrimPrefix(assetPath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(p.BaseURL, "/") + "/" + strings.Join(segments, "/")
+}
+
+func (p *Proxy) store(assetPath string, asset *Asset) {
+	if p.Cache == nil {
+		return
+	}
+	if err := p.Cache.Put(assetPath, asset); err != nil {
+		log.Printf("Error caching asset %s: %v", assetPath, err)
+	}
+}
+
+// ContentType guesses the content type of an asset from its extension, or
+// else its contents.
+func ContentType(assetPath string, body []byte) string {
+	if contentType := mime.TypeByExtension(path.Ext(assetPath)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(body)
+}
+
+// readAll reads at most limit bytes from r, failing on longer bodies.
+func readAll(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errTooLarge
+	}
+	return data, nil
+}
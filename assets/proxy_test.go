@@ -0,0 +1,184 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cdn is a fake CDN serving one image, counting the requests it gets.
+type cdn struct {
+	*httptest.Server
+	hits atomic.Int32
+	fail atomic.Bool
+
+	// revalidated counts the requests answered with 304 Not Modified.
+	revalidated atomic.Int32
+
+	// wait, when set, holds requests until it is closed.
+	wait chan struct{}
+}
+
+func newCDN(t *testing.T) *cdn {
+	c := &cdn{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.hits.Add(1)
+		if c.wait != nil {
+			<-c.wait
+		}
+		switch {
+		case c.fail.Load():
+			http.Error(w, "down", http.StatusBadGateway)
+		case r.URL.Path != "/characters/icons/rover.png":
+			http.NotFound(w, r)
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			c.revalidated.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte("rover"))
+		}
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+// clock is a time the test moves by hand, read by background fetches too.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestProxy returns a Proxy for server caching in a temporary directory,
+// with a clock the test moves by hand.
+func newTestProxy(t *testing.T, server *cdn) (*Proxy, *clock) {
+	cache, err := OpenDiskCache(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("OpenDiskCache() = %v", err)
+	}
+	now := &clock{now: time.Date(2024, time.May, 22, 12, 0, 0, 0, time.UTC)}
+	p := NewProxy(server.URL, cache, time.Second)
+	p.Now = now.Now
+	return p, now
+}
+
+func TestProxyCoalesces(t *testing.T) {
+	server := newCDN(t)
+	server.wait = make(chan struct{})
+	p, _ := newTestProxy(t, server)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			asset, err := p.Get(context.Background(), "characters/icons/rover.png")
+			if err != nil || string(asset.Body) != "rover" {
+				t.Errorf("Get() = %v, %v", asset, err)
+			}
+		}()
+	}
+	// Let every caller reach the fetch under way before it completes.
+	time.Sleep(100 * time.Millisecond)
+	close(server.wait)
+	wg.Wait()
+
+	if hits := server.hits.Load(); hits != 1 {
+		t.Errorf("10 concurrent requests made %d fetches, want 1", hits)
+	}
+}
+
+func TestProxyRevalidates(t *testing.T) {
+	server := newCDN(t)
+	p, now := newTestProxy(t, server)
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "characters/icons/rover.png"); err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	now.Add(p.MaxAge / 2)
+	if _, err := p.Get(ctx, "characters/icons/rover.png"); err != nil || server.hits.Load() != 1 {
+		t.Errorf("Get() within MaxAge = %v after %d fetches, want it served from the cache", err, server.hits.Load())
+	}
+
+	now.Add(p.MaxAge + p.StaleFor)
+	asset, err := p.Get(ctx, "characters/icons/rover.png")
+	if err != nil || string(asset.Body) != "rover" || !asset.Fetched.Equal(now.Now()) {
+		t.Fatalf("Get() past StaleFor = %+v, %v, want the cached copy revalidated", asset, err)
+	}
+	if server.revalidated.Load() != 1 {
+		t.Errorf("the CDN answered %d requests with 304, want 1", server.revalidated.Load())
+	}
+
+	if _, err := p.Get(ctx, "characters/icons/rover.png"); err != nil || server.hits.Load() != 2 {
+		t.Errorf("Get() after revalidating = %v after %d fetches, want it fresh again", err, server.hits.Load())
+	}
+}
+
+func TestProxyServesStale(t *testing.T) {
+	server := newCDN(t)
+	p, now := newTestProxy(t, server)
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "characters/icons/rover.png"); err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+
+	// Within StaleFor the copy is served and revalidated in the background.
+	now.Add(p.MaxAge + time.Minute)
+	if asset, err := p.Get(ctx, "characters/icons/rover.png"); err != nil || string(asset.Body) != "rover" {
+		t.Errorf("Get() within StaleFor = %v, %v, want the cached copy", asset, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for server.revalidated.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if server.revalidated.Load() != 1 {
+		t.Errorf("the stale copy was not revalidated in the background")
+	}
+
+	// Past it, a failing CDN still leaves the cached copy to serve.
+	server.fail.Store(true)
+	now.Add(p.MaxAge + p.StaleFor)
+	if asset, err := p.Get(ctx, "characters/icons/rover.png"); err != nil || string(asset.Body) != "rover" {
+		t.Errorf("Get() with the CDN down = %v, %v, want the cached copy", asset, err)
+	}
+}
+
+func TestProxyRemembersMissing(t *testing.T) {
+	server := newCDN(t)
+	p, now := newTestProxy(t, server)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.Get(ctx, "characters/icons/nobody.png"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get() = %v, want ErrNotFound", err)
+		}
+	}
+	if hits := server.hits.Load(); hits != 1 {
+		t.Errorf("3 requests for a missing asset made %d fetches, want 1", hits)
+	}
+
+	now.Add(p.MissingFor)
+	if _, err := p.Get(ctx, "characters/icons/nobody.png"); !errors.Is(err, ErrNotFound) || server.hits.Load() != 2 {
+		t.Errorf("Get() after MissingFor = %v after %d fetches, want the CDN asked again", err, server.hits.Load())
+	}
+}
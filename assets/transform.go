@@ -0,0 +1,91 @@
+package assets
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// Transform is how an image is resized and converted. A zero Width or Height
+// leaves that side to follow the aspect ratio, and an empty Format keeps the
+// image's own.
+type Transform struct {
+	Width  int
+	Height int
+	Format string
+}
+
+// formats are the formats images can be converted to, with their content
+// types.
+var formats = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+	"webp": "image/webp",
+}
+
+// ParseTransform reads a Transform from the size, w, h and format query
+// parameters. size sets both the width and height; dimensions go from 1 to
+// maxDimension.
+func ParseTransform(query url.Values, maxDimension int) (Transform, error) {
+	var t Transform
+	var err error
+	if size := query.Get("size"); size != "" {
+		if t.Width, err = parseDimension("size", size, maxDimension); err != nil {
+			return t, err
+		}
+		t.Height = t.Width
+	}
+	if w := query.Get("w"); w != "" {
+		if t.Width, err = parseDimension("w", w, maxDimension); err != nil {
+			return t, err
+		}
+	}
+	if h := query.Get("h"); h != "" {
+		if t.Height, err = parseDimension("h", h, maxDimension); err != nil {
+			return t, err
+		}
+	}
+
+	switch format := query.Get("format"); format {
+	case "":
+	case "jpg":
+		t.Format = "jpeg"
+	default:
+		if _, ok := formats[format]; !ok {
+			return t, fmt.Errorf("format must be png, webp or jpeg")
+		}
+		t.Format = format
+	}
+	return t, nil
+}
+
+func parseDimension(name, value string, maxDimension int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxDimension {
+		return 0, fmt.Errorf("%s must be a number from 1 to %d", name, maxDimension)
+	}
+	return n, nil
+}
+
+// IsZero reports whether t leaves images as they are.
+func (t Transform) IsZero() bool {
+	return t == Transform{}
+}
+
+func (t Transform) String() string {
+	return fmt.Sprintf("w=%d&h=%d&format=%s", t.Width, t.Height, t.Format)
+}
+
+// fit returns the size of a width by height image scaled down to fit t,
+// keeping its aspect ratio. Images are never enlarged.
+func (t Transform) fit(width, height int) (int, int) {
+	scale := 1.0
+	if t.Width > 0 {
+		scale = min(scale, float64(t.Width)/float64(width))
+	}
+	if t.Height > 0 {
+		scale = min(scale, float64(t.Height)/float64(height))
+	}
+	return max(1, int(math.Round(float64(width)*scale))), max(1, int(math.Round(float64(height)*scale)))
+}
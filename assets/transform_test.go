@@ -0,0 +1,137 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"testing"
+)
+
+func TestParseTransform(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Transform
+		err   bool
+	}{
+		{"", Transform{}, false},
+		{"size=64", Transform{Width: 64, Height: 64}, false},
+		{"w=100", Transform{Width: 100}, false},
+		{"h=100", Transform{Height: 100}, false},
+		{"w=100&h=50", Transform{Width: 100, Height: 50}, false},
+		{"size=64&w=32", Transform{Width: 32, Height: 64}, false},
+		{"size=1", Transform{Width: 1, Height: 1}, false},
+		{"size=512", Transform{Width: 512, Height: 512}, false},
+		{"format=webp", Transform{Format: "webp"}, false},
+		{"format=png", Transform{Format: "png"}, false},
+		{"format=jpg", Transform{Format: "jpeg"}, false},
+		{"format=jpeg&w=10", Transform{Width: 10, Format: "jpeg"}, false},
+		{"size=0", Transform{}, true},
+		{"size=513", Transform{}, true},
+		{"w=-1", Transform{}, true},
+		{"h=513", Transform{}, true},
+		{"w=big", Transform{}, true},
+		{"format=gif", Transform{}, true},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		got, err := ParseTransform(query, 512)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseTransform(%q) error = %v, want error %v", tt.query, err, tt.err)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("ParseTransform(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTransformFit(t *testing.T) {
+	tests := []struct {
+		name          string
+		t             Transform
+		width, height int
+		wantW, wantH  int
+	}{
+		{"nothing", Transform{}, 200, 100, 200, 100},
+		{"square", Transform{Width: 50, Height: 50}, 200, 100, 50, 25},
+		{"square, tall image", Transform{Width: 50, Height: 50}, 100, 200, 25, 50},
+		{"width only", Transform{Width: 100}, 200, 100, 100, 50},
+		{"height only", Transform{Height: 20}, 200, 100, 40, 20},
+		{"box, width binds", Transform{Width: 100, Height: 100}, 400, 100, 100, 25},
+		{"box, height binds", Transform{Width: 300, Height: 50}, 400, 100, 200, 50},
+		{"never enlarged", Transform{Width: 400, Height: 400}, 200, 100, 200, 100},
+		{"width only, never enlarged", Transform{Width: 400}, 200, 100, 200, 100},
+		{"rounded", Transform{Width: 100}, 300, 100, 100, 33},
+		{"at least one pixel", Transform{Width: 1}, 1000, 10, 1, 1},
+	}
+	for _, tt := range tests {
+		if w, h := tt.t.fit(tt.width, tt.height); w != tt.wantW || h != tt.wantH {
+			t.Errorf("%s: fit(%d, %d) = %dx%d, want %dx%d", tt.name, tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+// staticSource serves one asset for every path.
+type staticSource struct{ asset *Asset }
+
+func (s staticSource) Get(context.Context, string) (*Asset, error) { return s.asset, nil }
+
+func TestImagesTransform(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	original := &Asset{Body: buf.Bytes(), ContentType: "image/png", CacheControl: "max-age=60"}
+	im := NewImages(staticSource{original}, nil)
+
+	tests := []struct {
+		name          string
+		t             Transform
+		contentType   string
+		width, height int
+		same          bool
+	}{
+		{"unchanged", Transform{}, "image/png", 200, 100, true},
+		{"too large to enlarge", Transform{Width: 400}, "image/png", 200, 100, true},
+		{"same format", Transform{Format: "png"}, "image/png", 200, 100, true},
+		{"size", Transform{Width: 50, Height: 50}, "image/png", 50, 25, false},
+		{"width", Transform{Width: 100}, "image/png", 100, 50, false},
+		{"height", Transform{Height: 10}, "image/png", 20, 10, false},
+		{"webp", Transform{Format: "webp"}, "image/webp", 200, 100, false},
+		{"jpeg", Transform{Width: 64, Format: "jpeg"}, "image/jpeg", 64, 32, false},
+	}
+	for _, tt := range tests {
+		asset, err := im.Get(context.Background(), "characters/icons/rover.png", tt.t)
+		if err != nil {
+			t.Errorf("%s: Get() = %v", tt.name, err)
+			continue
+		}
+		if asset.ContentType != tt.contentType || asset.CacheControl != original.CacheControl {
+			t.Errorf("%s: content type %q, cache control %q", tt.name, asset.ContentType, asset.CacheControl)
+		}
+		if same := bytes.Equal(asset.Body, original.Body); same != tt.same {
+			t.Errorf("%s: body unchanged = %v, want %v", tt.name, same, tt.same)
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(asset.Body))
+		if err != nil {
+			t.Errorf("%s: DecodeConfig() = %v", tt.name, err)
+			continue
+		}
+		if config.Width != tt.width || config.Height != tt.height {
+			t.Errorf("%s: %dx%d, want %dx%d", tt.name, config.Width, config.Height, tt.width, tt.height)
+		}
+	}
+
+	im.MaxPixels = 100
+	if _, err := im.Get(context.Background(), "characters/icons/rover.png", Transform{Width: 10}); err == nil {
+		t.Error("Get() transformed an image larger than MaxPixels")
+	}
+}
@@ -0,0 +1,337 @@
+package assets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// encodeWebP writes img as a lossless WebP (VP8L) image. It applies the
+// subtract green and predictor transforms and Huffman codes the result,
+// which is far from the smallest encoding but needs no cgo.
+func encodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > 1<<14 || height > 1<<14 {
+		return fmt.Errorf("webp images are 1 to %d pixels wide and high", 1<<14)
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	pixels := make([]uint32, width*height)
+	opaque := true
+	for i := range pixels {
+		p := nrgba.Pix[i*4 : i*4+4]
+		pixels[i] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		opaque = opaque && p[3] == 0xff
+	}
+
+	var bw bitWriter
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if opaque {
+		bw.write(0, 1)
+	} else {
+		bw.write(1, 1)
+	}
+	bw.write(0, 3)
+
+	// Subtract green: red and blue are coded relative to green.
+	bw.write(1, 1)
+	bw.write(vp8lSubtractGreen, 2)
+	for i, p := range pixels {
+		g := p >> 8 & 0xff
+		r := (p>>16 - g) & 0xff
+		b := (p - g) & 0xff
+		pixels[i] = p&0xff00ff00 | r<<16 | b
+	}
+
+	// Predictor: every pixel is coded relative to its left neighbour, or the
+	// one above it in the first column.
+	bw.write(1, 1)
+	bw.write(vp8lPredictor, 2)
+	const blockBits = 9
+	bw.write(blockBits-2, 3)
+	blocks := make([]uint32, blocksFor(width, blockBits)*blocksFor(height, blockBits))
+	for i := range blocks {
+		blocks[i] = 0xff000000 | vp8lPredictLeft<<8
+	}
+	writeEntropyImage(&bw, blocks, false)
+	residuals := make([]uint32, len(pixels))
+	for y := range height {
+		for x := range width {
+			i := y*width + x
+			var predicted uint32
+			switch {
+			case x == 0 && y == 0:
+				predicted = 0xff000000
+			case x == 0:
+				predicted = pixels[i-width]
+			default:
+				predicted = pixels[i-1]
+			}
+			residuals[i] = subPixels(pixels[i], predicted)
+		}
+	}
+	bw.write(0, 1)
+
+	writeEntropyImage(&bw, residuals, true)
+	data := bw.bytes()
+
+	chunk := len(data) + len(data)%2
+	header := make([]byte, 20)
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+chunk))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// VP8L transform types and the predictor mode used.
+const (
+	vp8lPredictor     = 0
+	vp8lSubtractGreen = 2
+	vp8lPredictLeft   = 1
+)
+
+// codeLengthOrder is the order code length code lengths are written in.
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func blocksFor(size, bits int) int {
+	return (size + 1<<bits - 1) >> bits
+}
+
+// subPixels subtracts each channel of b from a, modulo 256.
+func subPixels(a, b uint32) uint32 {
+	var out uint32
+	for shift := 0; shift < 32; shift += 8 {
+		out |= ((a>>shift - b>>shift) & 0xff) << shift
+	}
+	return out
+}
+
+// writeEntropyImage writes pixels as literals with one prefix code per
+// channel and no color cache. Only the main image says whether it uses meta
+// prefix codes.
+func writeEntropyImage(bw *bitWriter, pixels []uint32, main bool) {
+	bw.write(0, 1)
+	if main {
+		bw.write(0, 1)
+	}
+
+	counts := [5][]int{make([]int, 256+24), make([]int, 256), make([]int, 256), make([]int, 256), make([]int, 40)}
+	for _, p := range pixels {
+		counts[0][p>>8&0xff]++
+		counts[1][p>>16&0xff]++
+		counts[2][p&0xff]++
+		counts[3][p>>24]++
+	}
+	var codes [5]prefixCode
+	for i := range codes {
+		codes[i] = newPrefixCode(counts[i], 15)
+		codes[i].writeTo(bw)
+	}
+	for _, p := range pixels {
+		codes[0].put(bw, int(p>>8&0xff))
+		codes[1].put(bw, int(p>>16&0xff))
+		codes[2].put(bw, int(p&0xff))
+		codes[3].put(bw, int(p>>24))
+	}
+}
+
+// prefixCode is a canonical Huffman code. codes hold the bits of each
+// symbol's code reversed, as they are written least significant bit first.
+type prefixCode struct {
+	lengths []int
+	codes   []uint32
+	used    []int
+}
+
+// newPrefixCode builds a Huffman code for counts with codes of at most
+// maxLength bits. A code with a single symbol takes no bits.
+func newPrefixCode(counts []int, maxLength int) prefixCode {
+	p := prefixCode{lengths: make([]int, len(counts)), codes: make([]uint32, len(counts))}
+	for symbol, count := range counts {
+		if count > 0 {
+			p.used = append(p.used, symbol)
+		}
+	}
+	switch len(p.used) {
+	case 0:
+		p.used = []int{0}
+		fallthrough
+	case 1:
+		p.lengths[p.used[0]] = 1
+		return p
+	}
+
+	weights := make([]int, len(counts))
+	copy(weights, counts)
+	for {
+		huffmanLengths(weights, p.used, p.lengths)
+		longest := 0
+		for _, symbol := range p.used {
+			longest = max(longest, p.lengths[symbol])
+		}
+		if longest <= maxLength {
+			break
+		}
+		// Flatten the counts until the tree is shallow enough.
+		for _, symbol := range p.used {
+			weights[symbol] = weights[symbol]>>1 | 1
+		}
+	}
+
+	var lengthCounts [16]int
+	for _, symbol := range p.used {
+		lengthCounts[p.lengths[symbol]]++
+	}
+	var next [16]uint32
+	code := uint32(0)
+	for length := 1; length < len(next); length++ {
+		code = (code + uint32(lengthCounts[length-1])) << 1
+		next[length] = code
+	}
+	for _, symbol := range p.used {
+		length := p.lengths[symbol]
+		p.codes[symbol] = reverse(next[length], length)
+		next[length]++
+	}
+	return p
+}
+
+// huffmanLengths sets the code length of each used symbol from a Huffman
+// tree of their weights.
+func huffmanLengths(weights []int, used []int, lengths []int) {
+	type node struct {
+		weight      int
+		symbol      int
+		left, right int
+	}
+	nodes := make([]node, 0, 2*len(used))
+	var live []int
+	for _, symbol := range used {
+		nodes = append(nodes, node{weight: weights[symbol], symbol: symbol, left: -1, right: -1})
+		live = append(live, len(nodes)-1)
+	}
+	smallest := func() int {
+		best := 0
+		for i := range live {
+			if nodes[live[i]].weight < nodes[live[best]].weight {
+				best = i
+			}
+		}
+		n := live[best]
+		live = append(live[:best], live[best+1:]...)
+		return n
+	}
+	for len(live) > 1 {
+		a, b := smallest(), smallest()
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+		live = append(live, len(nodes)-1)
+	}
+
+	var walk func(n, depth int)
+	walk = func(n, depth int) {
+		if nodes[n].left < 0 {
+			lengths[nodes[n].symbol] = depth
+			return
+		}
+		walk(nodes[n].left, depth+1)
+		walk(nodes[n].right, depth+1)
+	}
+	walk(live[0], 0)
+}
+
+func reverse(code uint32, length int) uint32 {
+	var out uint32
+	for range length {
+		out = out<<1 | code&1
+		code >>= 1
+	}
+	return out
+}
+
+// put writes the code of symbol.
+func (p prefixCode) put(bw *bitWriter, symbol int) {
+	if len(p.used) > 1 {
+		bw.write(p.codes[symbol], uint(p.lengths[symbol]))
+	}
+}
+
+// writeTo writes the code lengths of p. Codes of one or two symbols below
+// 256 use the simple form, listing the symbols.
+func (p prefixCode) writeTo(bw *bitWriter) {
+	if len(p.used) <= 2 && p.used[len(p.used)-1] < 256 {
+		bw.write(1, 1)
+		bw.write(uint32(len(p.used)-1), 1)
+		if first := p.used[0]; first < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(first), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(first), 8)
+		}
+		if len(p.used) == 2 {
+			bw.write(uint32(p.used[1]), 8)
+		}
+		return
+	}
+
+	bw.write(0, 1)
+	lengthCounts := make([]int, 19)
+	for _, length := range p.lengths {
+		lengthCounts[length]++
+	}
+	lengthCode := newPrefixCode(lengthCounts, 7)
+	n := 4
+	for i, symbol := range codeLengthOrder {
+		if lengthCode.lengths[symbol] > 0 {
+			n = max(n, i+1)
+		}
+	}
+	bw.write(uint32(n-4), 4)
+	for _, symbol := range codeLengthOrder[:n] {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bw.write(0, 1)
+	for _, length := range p.lengths {
+		lengthCode.put(bw, length)
+	}
+}
+
+// bitWriter packs bits least significant first.
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	bits uint
+}
+
+func (b *bitWriter) write(v uint32, n uint) {
+	b.acc |= uint64(v) << b.bits
+	b.bits += n
+	for b.bits >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.bits -= 8
+	}
+}
+
+func (b *bitWriter) bytes() []byte {
+	if b.bits > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.bits = 0, 0
+	}
+	return b.buf
+}
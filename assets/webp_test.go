@@ -0,0 +1,95 @@
+package assets
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := func(w, h int, opaque bool) image.Image {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		rng.Read(img.Pix)
+		if opaque {
+			for i := 3; i < len(img.Pix); i += 4 {
+				img.Pix[i] = 0xff
+			}
+		}
+		return img
+	}
+	gradient := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := range 48 {
+		for x := range 64 {
+			gradient.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 5), uint8(x + y), 0xff})
+		}
+	}
+	flat := image.NewUniform(color.NRGBA{0x20, 0x40, 0x60, 0x80})
+	offset := image.NewNRGBA(image.Rect(10, 20, 30, 25))
+	rng.Read(offset.Pix)
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"single pixel", noise(1, 1, false)},
+		{"opaque noise", noise(37, 23, true)},
+		{"translucent noise", noise(23, 37, false)},
+		// Wider and higher than one 512 pixel predictor block.
+		{"several blocks", noise(600, 530, false)},
+		{"gradient", gradient},
+		{"uniform", &uniformImage{flat, image.Rect(0, 0, 16, 16)}},
+		{"offset bounds", offset},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := encodeWebP(&buf, tt.img); err != nil {
+			t.Errorf("%s: encodeWebP() = %v", tt.name, err)
+			continue
+		}
+		decoded, err := webp.Decode(&buf)
+		if err != nil {
+			t.Errorf("%s: webp.Decode() = %v", tt.name, err)
+			continue
+		}
+
+		bounds := tt.img.Bounds()
+		want := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(want, want.Bounds(), tt.img, bounds.Min, draw.Src)
+		if decoded.Bounds() != want.Bounds() {
+			t.Errorf("%s: decoded bounds %v, want %v", tt.name, decoded.Bounds(), want.Bounds())
+			continue
+		}
+	pixels:
+		for y := range want.Bounds().Dy() {
+			for x := range want.Bounds().Dx() {
+				got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+				if w := want.NRGBAAt(x, y); got != w {
+					t.Errorf("%s: pixel (%d, %d) = %v, want %v", tt.name, x, y, got, w)
+					break pixels
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeWebPSize(t *testing.T) {
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 0, 10), image.Rect(0, 0, 1<<14+1, 1)} {
+		if err := encodeWebP(&bytes.Buffer{}, image.NewNRGBA(r)); err == nil {
+			t.Errorf("encodeWebP(%v) succeeded", r)
+		}
+	}
+}
+
+// uniformImage is an image of one colour with bounds.
+type uniformImage struct {
+	*image.Uniform
+	bounds image.Rectangle
+}
+
+func (u *uniformImage) Bounds() image.Rectangle { return u.bounds }
@@ -0,0 +1,381 @@
+package calc
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"api/models"
+	"api/store"
+)
+
+// Build limits: a character equips up to MaxEchoes echoes whose costs add up
+// to at most MaxEchoCost. Sonata set bonuses unlock at TwoPiece and FivePiece
+// different echoes of the same sonata.
+const (
+	MaxEchoes   = 5
+	MaxEchoCost = 12
+	TwoPiece    = 2
+	FivePiece   = 5
+)
+
+// BuildWeapon is the weapon equipped in a build. Level defaults to 1.
+type BuildWeapon struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Refinement int    `json:"refinement"`
+	Level      int    `json:"level"`
+}
+
+// BuildEcho is an equipped echo. Cost may be left out; Sonata may be left out
+// when the echo belongs to a single sonata; Level defaults to MaxEchoLevel.
+type BuildEcho struct {
+	Name     string      `json:"name"`
+	Cost     int         `json:"cost"`
+	Level    *int        `json:"level"`
+	MainStat string      `json:"mainStat"`
+	Sonata   string      `json:"sonata"`
+	Substats []StatValue `json:"substats"`
+}
+
+// BuildInput is a character build to evaluate.
+type BuildInput struct {
+	Character string      `json:"character"`
+	Weapon    BuildWeapon `json:"weapon"`
+	Echoes    []BuildEcho `json:"echoes"`
+}
+
+// EvaluatedEcho is an equipped echo with its stats resolved.
+type EvaluatedEcho struct {
+	Name           string      `json:"name"`
+	Cost           int         `json:"cost"`
+	Level          int         `json:"level"`
+	Sonata         string      `json:"sonata"`
+	MainStat       StatValue   `json:"mainStat"`
+	SecondaryStats []StatValue `json:"secondaryStats"`
+	Substats       []StatValue `json:"substats"`
+}
+
+// ActiveSonata is a sonata with at least a two-piece bonus in effect.
+type ActiveSonata struct {
+	Name      string `json:"name"`
+	Pieces    int    `json:"pieces"`
+	TwoPiece  string `json:"twoPiece"`
+	FivePiece string `json:"fivePiece,omitempty"`
+}
+
+// BuildResult is an evaluated build. Totals add up the stats of the weapon,
+// the echoes and the sonata bonuses that grant a plain stat.
+type BuildResult struct {
+	Character string          `json:"character"`
+	Attribute string          `json:"attribute,omitempty"`
+	Weapon    BuildWeaponInfo `json:"weapon"`
+	Cost      int             `json:"cost"`
+	MaxCost   int             `json:"maxCost"`
+	Echoes    []EvaluatedEcho `json:"echoes"`
+	Sonatas   []ActiveSonata  `json:"sonatas"`
+	Totals    []StatValue     `json:"totals"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+// BuildWeaponInfo describes the equipped weapon in a BuildResult.
+type BuildWeaponInfo struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Level      int         `json:"level"`
+	Refinement int         `json:"refinement"`
+	Stats      []StatValue `json:"stats"`
+	Skill      string      `json:"skill,omitempty"`
+}
+
+// EvaluateBuild validates a build against the data in s and computes its
+// stat totals and active sonata bonuses.
+func EvaluateBuild(s store.Store, in BuildInput) (BuildResult, error) {
+	s = s.Snapshot()
+	var errs ValidationErrors
+
+	result := BuildResult{MaxCost: MaxEchoCost, Echoes: []EvaluatedEcho{}, Sonatas: []ActiveSonata{}}
+	totals := newStatTotals(s.Substats())
+
+	character, ok := s.Character(in.Character)
+	if !ok {
+		errs.add("character", "unknown character %q", in.Character)
+	} else {
+		result.Character = characterName(character)
+		result.Attribute = character.Attribute
+	}
+
+	if in.Weapon.Refinement == 0 {
+		in.Weapon.Refinement = 1
+	}
+	if in.Weapon.Level == 0 {
+		in.Weapon.Level = DefaultCharacterLevel
+	}
+	weapon, ok := s.Weapon(in.Weapon.Type, in.Weapon.Name)
+	if !ok {
+		errs.add("weapon", "unknown weapon %q of type %q", in.Weapon.Name, in.Weapon.Type)
+	} else {
+		info, err := weaponInfo(weapon, in.Weapon.Level, in.Weapon.Refinement)
+		var weaponErrs ValidationErrors
+		if errors.As(err, &weaponErrs) {
+			for _, e := range weaponErrs {
+				errs.add("weapon."+e.Field, "%s", e.Message)
+			}
+		}
+		for _, stat := range info.Stats {
+			totals.add(stat)
+		}
+		result.Weapon = info
+		if len(weapon.Stats.Levels) == 0 && weapon.Stats.Attack != 0 && in.Weapon.Level > 1 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("the stats of %s above level 1 are not in the data, its level 1 stats are used", weapon.Name))
+		}
+
+		// The character's weapon type is resolved like a route parameter, so
+		// "Gauntlet" finds the gauntlets.
+		if _, known := s.Weapons(character.Weapon); known {
+			if _, same := s.Weapon(character.Weapon, weapon.Name); !same {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s uses %s, %s is a %s", result.Character, character.Weapon, weapon.Name, weapon.Type))
+			}
+		}
+	}
+
+	if len(in.Echoes) > MaxEchoes {
+		errs.add("echoes", "at most %d echoes can be equipped", MaxEchoes)
+	}
+
+	bySlug := substatIndex(s.Substats())
+	pieces := make(map[string]map[string]bool)
+	for i, e := range in.Echoes {
+		field := fmt.Sprintf("echoes[%d]", i)
+
+		echo, ok := s.Echo(e.Name)
+		if !ok {
+			errs.add(field+".name", "unknown echo %q", e.Name)
+			continue
+		}
+		if e.Cost != 0 && e.Cost != echo.Cost {
+			errs.add(field+".cost", "%s costs %d, not %d", echo.Name, echo.Cost, e.Cost)
+		}
+		result.Cost += echo.Cost
+
+		sonata, ok := echoSonata(echo, e.Sonata)
+		if !ok {
+			if e.Sonata == "" {
+				errs.add(field+".sonata", "%s can roll %s, pick one", echo.Name, strings.Join(echo.SonataEffects, ", "))
+			} else {
+				errs.add(field+".sonata", "%s cannot roll %q, expected one of %s", echo.Name, e.Sonata, strings.Join(echo.SonataEffects, ", "))
+			}
+		}
+
+		level := MaxEchoLevel
+		if e.Level != nil {
+			level = *e.Level
+		}
+		main, secondary, err := MainStats(s.Stats(), echo.Cost, e.MainStat, level)
+		var mainErrs ValidationErrors
+		if errors.As(err, &mainErrs) {
+			for _, e := range mainErrs {
+				errs.add(field+"."+e.Field, "%s", e.Message)
+			}
+		}
+
+		if len(e.Substats) > level/SubstatInterval {
+			errs.add(field+".substats", "a level %d echo has at most %d substats", level, level/SubstatInterval)
+		}
+		seen := make(map[string]bool)
+		rolls := []StatValue{}
+		for j, roll := range e.Substats {
+			substat, ok := checkRoll(bySlug, roll, fmt.Sprintf("%s.substats[%d]", field, j), seen, &errs)
+			if ok {
+				rolls = append(rolls, StatValue{Name: substat.Name, Value: roll.Value})
+			}
+		}
+
+		if err != nil || !ok {
+			continue
+		}
+
+		main.Name = elementStat(main.Name, character.Attribute)
+		totals.add(main)
+		for _, stat := range secondary {
+			totals.add(stat)
+		}
+		for _, roll := range rolls {
+			totals.add(roll)
+		}
+
+		if pieces[sonata] == nil {
+			pieces[sonata] = make(map[string]bool)
+		}
+		pieces[sonata][echo.Name] = true
+
+		result.Echoes = append(result.Echoes, EvaluatedEcho{
+			Name:           echo.Name,
+			Cost:           echo.Cost,
+			Level:          level,
+			Sonata:         sonata,
+			MainStat:       main,
+			SecondaryStats: secondary,
+			Substats:       rolls,
+		})
+	}
+
+	if result.Cost > MaxEchoCost {
+		errs.add("echoes", "total cost %d exceeds %d", result.Cost, MaxEchoCost)
+	}
+
+	if err := errs.err(); err != nil {
+		return BuildResult{}, err
+	}
+
+	for _, sonata := range s.Sonatas() {
+		count := len(pieces[sonata.Name])
+		if count < TwoPiece {
+			continue
+		}
+		active := ActiveSonata{Name: sonata.Name, Pieces: count, TwoPiece: sonata.TwoPiece}
+		if bonus, ok := sonataBonus(sonata.TwoPiece); ok {
+			totals.add(bonus)
+		}
+		if count >= FivePiece {
+			active.FivePiece = sonata.FivePiece
+		}
+		result.Sonatas = append(result.Sonatas, active)
+	}
+
+	result.Totals = totals.list()
+	return result, nil
+}
+
+// echoSonata picks the sonata an equipped echo counts towards.
+func echoSonata(echo models.Echo, requested string) (string, bool) {
+	if requested == "" {
+		if len(echo.SonataEffects) == 1 {
+			return echo.SonataEffects[0], true
+		}
+		return "", false
+	}
+	for _, sonata := range echo.SonataEffects {
+		if store.Slug(sonata) == store.Slug(requested) {
+			return sonata, true
+		}
+	}
+	return "", false
+}
+
+// weaponInfo resolves a weapon's stats at level and its rendered skill.
+func weaponInfo(weapon models.Weapon, level, refinement int) (BuildWeaponInfo, error) {
+	info := BuildWeaponInfo{Name: weapon.Name, Type: weapon.Type, Level: level, Refinement: refinement, Stats: []StatValue{}}
+
+	skill, err := RenderWeaponSkill(weapon, refinement)
+	if err != nil {
+		return info, err
+	}
+	info.Skill = skill
+
+	if len(weaponPoints(weapon)) == 0 {
+		return info, nil
+	}
+	stats, err := WeaponStatsAt(weapon, level, false)
+	if err != nil {
+		return info, err
+	}
+	info.Stats = append(info.Stats, StatValue{Name: "ATK", Value: stats.ATK})
+	if sub := stats.Substat; sub.Name != "" {
+		name := sub.Name
+		if sub.Unit == "%" && isFlatStat(name) {
+			name += "%"
+		}
+		info.Stats = append(info.Stats, StatValue{Name: name, Value: sub.Value})
+	}
+	return info, nil
+}
+
+// isFlatStat reports whether name is a stat that also exists as a percentage.
+func isFlatStat(name string) bool {
+	switch store.Slug(name) {
+	case "atk", "hp", "def":
+		return true
+	}
+	return false
+}
+
+// elementStat names the generic "[Element] DMG" main stat after attribute.
+func elementStat(name, attribute string) string {
+	if attribute == "" || !strings.Contains(name, "[Element]") {
+		return name
+	}
+	return strings.ReplaceAll(name, "[Element]", attribute)
+}
+
+var sonataBonusPattern = regexp.MustCompile(`(?i)^(.+?)\s+(?:is\s+)?increase[sd]?\s+by\s+([\d.]+)%`)
+
+// sonataBonus reads a plain stat bonus such as "Glacio damage increased by
+// 10%" from a set effect. Conditional effects are not recognised.
+func sonataBonus(effect string) (StatValue, bool) {
+	match := sonataBonusPattern.FindStringSubmatch(effect)
+	if match == nil {
+		return StatValue{}, false
+	}
+	value, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return StatValue{}, false
+	}
+
+	name := match[1]
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, " damage"), strings.HasSuffix(lower, " dmg"):
+		name = name[:strings.LastIndex(name, " ")] + " DMG"
+	case lower == "healing":
+		name = "Healing Bonus"
+	case isFlatStat(name):
+		name += "%"
+	}
+	return StatValue{Name: name, Value: value}, true
+}
+
+// statTotals adds up stats, merging names that differ only in spelling such
+// as "CRIT Rate" and "Crit Rate". Names of substats are preferred for display.
+type statTotals struct {
+	names  map[string]string
+	values map[string]float64
+}
+
+func newStatTotals(substats []models.Substat) *statTotals {
+	t := &statTotals{names: make(map[string]string), values: make(map[string]float64)}
+	for _, substat := range substats {
+		t.names[statKey(substat.Name)] = substat.Name
+	}
+	return t
+}
+
+// statKey keeps the '%' that tells ATK% from ATK when comparing names.
+func statKey(name string) string {
+	return store.Slug(strings.ReplaceAll(name, "%", " percent"))
+}
+
+func (t *statTotals) add(stat StatValue) {
+	key := statKey(stat.Name)
+	if _, ok := t.names[key]; !ok {
+		t.names[key] = stat.Name
+	}
+	t.values[key] += stat.Value
+}
+
+func (t *statTotals) get(name string) float64 {
+	return t.values[statKey(name)]
+}
+
+func (t *statTotals) list() []StatValue {
+	stats := make([]StatValue, 0, len(t.values))
+	for key, value := range t.values {
+		stats = append(stats, StatValue{Name: t.names[key], Value: round(value)})
+	}
+	slices.SortFunc(stats, func(a, b StatValue) int { return cmp.Compare(a.Name, b.Name) })
+	return stats
+}
@@ -0,0 +1,109 @@
+package calc
+
+import (
+	"testing"
+
+	"api/models"
+	"api/store"
+)
+
+func TestSonataBonus(t *testing.T) {
+	tests := []struct {
+		effect string
+		want   StatValue
+		ok     bool
+	}{
+		{"Glacio damage increased by 10%", StatValue{"Glacio DMG", 10}, true},
+		{"Fusion damage is increased by 10%", StatValue{"Fusion DMG", 10}, true},
+		{"Aero DMG increased by 10%", StatValue{"Aero DMG", 10}, true},
+		{"Spectro DMG is increased by 10%", StatValue{"Spectro DMG", 10}, true},
+		{"Healing is increased by 10%", StatValue{"Healing Bonus", 10}, true},
+		{"Energy Regen increased by 10%", StatValue{"Energy Regen", 10}, true},
+		{"ATK increases by 10%", StatValue{"ATK%", 10}, true},
+		{"Increases Spectro damage by 30% over 15s when releasing Intro Skill", StatValue{}, false},
+		{"", StatValue{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := sonataBonus(tt.effect)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("sonataBonus(%q) = %+v, %v, want %+v, %v", tt.effect, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEvaluateBuildSetBonuses(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+	echo := func(name, sonata string) models.Echo {
+		return models.Echo{Name: name, Cost: 1, SonataEffects: []string{sonata}}
+	}
+	s := store.NewMemory(store.Data{
+		Characters: []models.Character{{Name: "Lingyang", Attribute: "Glacio", Weapon: "Gauntlets", Rarity: 5}},
+		Weapons:    map[string][]models.Weapon{"Gauntlets": {{Name: "Abyss Surges", Type: "Gauntlets", Rarity: 5}}},
+		Echoes: []models.Echo{
+			echo("Frost 1", "Freezing Frost"), echo("Frost 2", "Freezing Frost"), echo("Frost 3", "Freezing Frost"),
+			echo("Frost 4", "Freezing Frost"), echo("Frost 5", "Freezing Frost"),
+			echo("Rift 1", "Molten Rift"), echo("Rift 2", "Molten Rift"),
+			echo("Tunes 1", "Lingering Tunes"), echo("Tunes 2", "Lingering Tunes"),
+		},
+		Sonatas:  m.Sonatas(),
+		Stats:    m.Stats(),
+		Substats: m.Substats(),
+	})
+	mainATK, _, err := MainStats(m.Stats(), 1, "ATK%", MaxEchoLevel)
+	if err != nil {
+		t.Fatalf("MainStats() = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		echoes  []string
+		sonatas map[string]int
+		totals  map[string]float64
+	}{
+		{"one piece", []string{"Frost 1"}, map[string]int{}, map[string]float64{"Glacio DMG": 0}},
+		{"two pieces", []string{"Frost 1", "Frost 2"}, map[string]int{"Freezing Frost": 2}, map[string]float64{"Glacio DMG": 10}},
+		// The five-piece effect is conditional, so only the two-piece counts.
+		{"five pieces", []string{"Frost 1", "Frost 2", "Frost 3", "Frost 4", "Frost 5"}, map[string]int{"Freezing Frost": 5}, map[string]float64{"Glacio DMG": 10}},
+		// The same echo twice is one piece.
+		{"repeated echo", []string{"Frost 1", "Frost 1"}, map[string]int{}, map[string]float64{"Glacio DMG": 0}},
+		{"two sets", []string{"Frost 1", "Frost 2", "Rift 1", "Rift 2", "Tunes 1"}, map[string]int{"Freezing Frost": 2, "Molten Rift": 2}, map[string]float64{"Glacio DMG": 10, "Fusion DMG": 10}},
+		// The ATK bonus adds up with the ATK% main stats.
+		{"stat bonus", []string{"Tunes 1", "Tunes 2"}, map[string]int{"Lingering Tunes": 2}, map[string]float64{"ATK%": round(2*mainATK.Value + 10)}},
+	}
+	for _, tt := range tests {
+		in := BuildInput{Character: "Lingyang", Weapon: BuildWeapon{Type: "Gauntlets", Name: "Abyss Surges"}}
+		for _, name := range tt.echoes {
+			in.Echoes = append(in.Echoes, BuildEcho{Name: name, MainStat: "ATK%"})
+		}
+		result, err := EvaluateBuild(s, in)
+		if err != nil {
+			t.Errorf("%s: EvaluateBuild() = %v", tt.name, err)
+			continue
+		}
+
+		if len(result.Sonatas) != len(tt.sonatas) {
+			t.Errorf("%s: active sonatas %+v, want %v", tt.name, result.Sonatas, tt.sonatas)
+		}
+		for _, active := range result.Sonatas {
+			if active.Pieces != tt.sonatas[active.Name] {
+				t.Errorf("%s: %s has %d pieces, want %d", tt.name, active.Name, active.Pieces, tt.sonatas[active.Name])
+			}
+			if (active.FivePiece != "") != (active.Pieces >= FivePiece) {
+				t.Errorf("%s: %s five-piece effect %q at %d pieces", tt.name, active.Name, active.FivePiece, active.Pieces)
+			}
+		}
+
+		totals := make(map[string]float64)
+		for _, stat := range result.Totals {
+			totals[stat.Name] = stat.Value
+		}
+		for name, want := range tt.totals {
+			if totals[name] != want {
+				t.Errorf("%s: total %s = %g, want %g", tt.name, name, totals[name], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package calc
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"api/models"
+)
+
+// LevelCaps is the maximum character level at each ascension phase, used
+// when a character's data does not list its ascension phases.
+var LevelCaps = [models.MaxAscension + 1]int{20, 40, 50, 60, 70, 80, 90}
+
+// CharacterStats are a character's base stats at a level.
+type CharacterStats struct {
+	Name      string  `json:"name"`
+	Level     int     `json:"level"`
+	Ascension int     `json:"ascension"`
+	MaxLevel  int     `json:"maxLevel"`
+	HP        float64 `json:"hp"`
+	ATK       float64 `json:"atk"`
+	DEF       float64 `json:"def"`
+}
+
+// characterName returns the name of character as written, undoing the escape
+// the loader gives spaces so names can be used in links.
+func characterName(character models.Character) string {
+	return strings.ReplaceAll(character.Name, "%20", " ")
+}
+
+// levelCaps returns the level cap of each ascension phase of character,
+// taking LevelCaps for the phases its data does not list.
+func levelCaps(character models.Character) []int {
+	caps := slices.Clone(LevelCaps[:])
+	for i, phase := range character.Ascension {
+		if i+1 < len(caps) {
+			caps[i+1] = phase.MaxLevel
+		}
+	}
+	return caps
+}
+
+// StatsAt interpolates a character's base stats at level. A level at a cap
+// is taken before ascending unless ascended is set, which takes it after.
+func StatsAt(character models.Character, level int, ascended bool) (CharacterStats, error) {
+	var errs ValidationErrors
+	if len(character.Stats) == 0 {
+		errs.add("name", "no stats are known for %s", characterName(character))
+		return CharacterStats{}, errs
+	}
+
+	caps := levelCaps(character)
+	phase, ok := phaseAt(caps, level, ascended, &errs)
+	if !ok {
+		return CharacterStats{}, errs
+	}
+
+	lo, hi, frac, ok := bracket(character.Stats, phase, level, func(p models.StatPoint) (int, int) { return p.Level, p.Ascension })
+	if !ok {
+		errs.add("level", "no stats are known for %s at level %d, ascension %d", characterName(character), level, phase)
+		return CharacterStats{}, errs
+	}
+	point := models.StatPoint{
+		HP:  lerp(lo.HP, hi.HP, frac),
+		ATK: lerp(lo.ATK, hi.ATK, frac),
+		DEF: lerp(lo.DEF, hi.DEF, frac),
+	}
+
+	return CharacterStats{
+		Name:      characterName(character),
+		Level:     level,
+		Ascension: phase,
+		MaxLevel:  caps[phase],
+		HP:        round(point.HP),
+		ATK:       round(point.ATK),
+		DEF:       round(point.DEF),
+	}, nil
+}
+
+// phaseAt returns the ascension phase a level is reached at given the level
+// cap of each phase. A level at a cap belongs to the phase it caps unless
+// ascended is set.
+func phaseAt(caps []int, level int, ascended bool, errs *ValidationErrors) (int, bool) {
+	maxLevel := caps[len(caps)-1]
+	if level < 1 || level > maxLevel {
+		errs.add("level", "must be between 1 and %d", maxLevel)
+		return 0, false
+	}
+
+	phase, _ := slices.BinarySearch(caps, level)
+	if ascended {
+		if level != caps[phase] || phase == len(caps)-1 {
+			errs.add("ascended", "level %d is not a level cap", level)
+			return 0, false
+		}
+		phase++
+	}
+	return phase, true
+}
+
+// bracket finds the points of an ascension phase on either side of level and
+// how far level lies between them. at returns a point's level and phase.
+func bracket[T any](points []T, phase, level int, at func(T) (int, int)) (lo, hi T, frac float64, ok bool) {
+	levelOf := func(point T) int {
+		l, _ := at(point)
+		return l
+	}
+
+	var inPhase []T
+	for _, point := range points {
+		if _, p := at(point); p == phase {
+			inPhase = append(inPhase, point)
+		}
+	}
+	slices.SortFunc(inPhase, func(a, b T) int { return cmp.Compare(levelOf(a), levelOf(b)) })
+
+	i, found := slices.BinarySearchFunc(inPhase, level, func(point T, level int) int { return cmp.Compare(levelOf(point), level) })
+	switch {
+	case found:
+		return inPhase[i], inPhase[i], 0, true
+	case i == 0 || i == len(inPhase):
+		return lo, hi, 0, false
+	}
+	lo, hi = inPhase[i-1], inPhase[i]
+	return lo, hi, float64(level-levelOf(lo)) / float64(levelOf(hi)-levelOf(lo)), true
+}
+
+func lerp(lo, hi, frac float64) float64 {
+	return lo + (hi-lo)*frac
+}
+
+// SkillsAt returns character's skills with the value of every multiplier at
+// skill level filled in.
+func SkillsAt(character models.Character, level int) ([]models.CharacterSkill, error) {
+	if level < 1 || level > models.MaxSkillLevel {
+		var errs ValidationErrors
+		errs.add("level", "must be between 1 and %d", models.MaxSkillLevel)
+		return nil, errs
+	}
+
+	skills := make([]models.CharacterSkill, len(character.Skills))
+	for i, skill := range character.Skills {
+		multipliers := make([]models.SkillMultiplier, len(skill.Multipliers))
+		for j, multiplier := range skill.Multipliers {
+			multiplier.Level = level
+			if level <= len(multiplier.Values) {
+				value := multiplier.Values[level-1]
+				multiplier.Value = &value
+			}
+			multipliers[j] = multiplier
+		}
+		skill.Multipliers = multipliers
+		skills[i] = skill
+	}
+	return skills, nil
+}
@@ -0,0 +1,64 @@
+package calc
+
+import (
+	"slices"
+	"testing"
+
+	"api/models"
+	"api/store"
+)
+
+// TestStatsAtData interpolates every character of the shipped data across
+// its levels, checking that the stats never drop as a character levels up.
+func TestStatsAtData(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+
+	for _, character := range m.Characters() {
+		var last CharacterStats
+		for level := 1; level <= models.MaxCharacterLevel; level++ {
+			for _, ascended := range []bool{false, true} {
+				if ascended && (level == models.MaxCharacterLevel || !slices.Contains(LevelCaps[:], level)) {
+					continue
+				}
+				stats, err := StatsAt(character, level, ascended)
+				if err != nil {
+					t.Errorf("StatsAt(%s, %d, %v) = %v", character.Name, level, ascended, err)
+					continue
+				}
+				if stats.HP < last.HP || stats.ATK < last.ATK || stats.DEF < last.DEF {
+					t.Errorf("StatsAt(%s, %d, %v) = %+v, below %+v", character.Name, level, ascended, stats, last)
+				}
+				last = stats
+			}
+		}
+	}
+}
+
+func TestStatsAt(t *testing.T) {
+	character := models.Character{
+		Name: "Xiangli%20Yao",
+		Stats: []models.StatPoint{
+			{Level: 1, Ascension: 0, HP: 100, ATK: 10, DEF: 20},
+			{Level: 20, Ascension: 0, HP: 290, ATK: 29, DEF: 58},
+			{Level: 20, Ascension: 1, HP: 400, ATK: 40, DEF: 80},
+		},
+	}
+
+	stats, err := StatsAt(character, 10, false)
+	if err != nil {
+		t.Fatalf("StatsAt(10) = %v", err)
+	}
+	if stats.Name != "Xiangli Yao" || stats.HP != 190 || stats.ATK != 19 || stats.DEF != 38 {
+		t.Errorf("StatsAt(10) = %+v, want halfway between the level 1 and 20 points", stats)
+	}
+
+	if stats, err := StatsAt(character, 20, true); err != nil || stats.Ascension != 1 || stats.HP != 400 {
+		t.Errorf("StatsAt(20, ascended) = %+v, %v, want the ascension 1 point", stats, err)
+	}
+	if _, err := StatsAt(character, 30, false); err == nil {
+		t.Errorf("StatsAt(30) succeeded without points for ascension 1 at level 30")
+	}
+}
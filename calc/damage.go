@@ -0,0 +1,307 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"api/models"
+	"api/store"
+)
+
+// Constants of the damage formula. Every character starts with BaseCritRate
+// and BaseCritDMG; an enemy's DEF is EnemyDEFPerLevel per level plus
+// EnemyDEFBase, and a character's level offsets it through the attacker term
+// AttackerDEFBase + AttackerDEFPerLevel × level.
+const (
+	DefaultCharacterLevel = 90
+	DefaultEnemyLevel     = 90
+	DefaultResistance     = 10
+
+	BaseCritRate = 5
+	BaseCritDMG  = 150
+
+	EnemyDEFBase        = 792
+	EnemyDEFPerLevel    = 8
+	AttackerDEFBase     = 800
+	AttackerDEFPerLevel = 8
+)
+
+// DamageBase overrides a character's base stats, for characters whose level
+// curve is not in the data yet.
+type DamageBase struct {
+	HP  float64 `json:"hp"`
+	ATK float64 `json:"atk"`
+	DEF float64 `json:"def"`
+}
+
+// DamageSkill selects the hit to compute. Either Multiplier is given, in
+// percent of Scaling, or Name names a multiplier of the character's skills,
+// optionally narrowed by Type, read at Level. Bonus is any further DMG bonus
+// in percent that applies to the hit, such as a Basic Attack DMG bonus.
+type DamageSkill struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Level      int     `json:"level"`
+	Multiplier float64 `json:"multiplier"`
+	Scaling    string  `json:"scaling"`
+	Bonus      float64 `json:"bonus"`
+}
+
+// DamageEnemy describes the target. Resistance is in percent and defaults to
+// DefaultResistance; ResistanceReduction and DefenseIgnore are in percent.
+type DamageEnemy struct {
+	Level               int      `json:"level"`
+	Resistance          *float64 `json:"resistance"`
+	ResistanceReduction float64  `json:"resistanceReduction"`
+	DefenseIgnore       float64  `json:"defenseIgnore"`
+}
+
+// DamageInput is a build, the character's level and the hit to compute.
+// Buffs are extra stats on top of the build, such as conditional set effects.
+type DamageInput struct {
+	BuildInput
+	Level    int         `json:"level"`
+	Ascended bool        `json:"ascended"`
+	Base     *DamageBase `json:"base"`
+	Skill    DamageSkill `json:"skill"`
+	Buffs    []StatValue `json:"buffs"`
+	Enemy    DamageEnemy `json:"enemy"`
+}
+
+// DamageStep is one step of the damage formula, kept so the result can be
+// checked by hand.
+type DamageStep struct {
+	Name    string  `json:"name"`
+	Formula string  `json:"formula"`
+	Value   float64 `json:"value"`
+}
+
+// Damage is the damage of one hit without and with a critical hit, and its
+// expectation given the crit rate.
+type Damage struct {
+	NonCrit  float64 `json:"nonCrit"`
+	Crit     float64 `json:"crit"`
+	Expected float64 `json:"expected"`
+}
+
+// DamageResult is a computed hit along with the build it was computed for.
+type DamageResult struct {
+	Character string       `json:"character"`
+	Level     int          `json:"level"`
+	Base      DamageBase   `json:"base"`
+	Build     BuildResult  `json:"build"`
+	Totals    []StatValue  `json:"totals"`
+	Steps     []DamageStep `json:"steps"`
+	Damage    Damage       `json:"damage"`
+	Warnings  []string     `json:"warnings,omitempty"`
+}
+
+// CalculateDamage evaluates the build in input and computes the damage of the
+// requested hit against the enemy:
+//
+//	scaling stat = (character base + weapon base) × (1 + stat%) + flat stat
+//	base damage  = scaling stat × multiplier
+//	damage       = base damage × (1 + DMG bonus) × DEF multiplier × RES multiplier
+//
+// with the crit damage and crit rate applied on top.
+func CalculateDamage(s store.Store, in DamageInput) (DamageResult, error) {
+	s = s.Snapshot()
+	var errs ValidationErrors
+
+	if in.Level == 0 {
+		in.Level = DefaultCharacterLevel
+	}
+	// The weapon is levelled along with the character unless told otherwise.
+	if in.Weapon.Level == 0 {
+		in.Weapon.Level = min(max(in.Level, 1), models.MaxCharacterLevel)
+	}
+	build, err := EvaluateBuild(s, in.BuildInput)
+	var buildErrs ValidationErrors
+	if errors.As(err, &buildErrs) {
+		errs = append(errs, buildErrs...)
+	} else if err != nil {
+		return DamageResult{}, err
+	}
+
+	if in.Enemy.Level == 0 {
+		in.Enemy.Level = DefaultEnemyLevel
+	}
+	if in.Enemy.Level < 1 {
+		errs.add("enemy.level", "must be positive")
+	}
+	resistance := float64(DefaultResistance)
+	if in.Enemy.Resistance != nil {
+		resistance = *in.Enemy.Resistance
+	}
+	if in.Enemy.DefenseIgnore < 0 || in.Enemy.DefenseIgnore > 100 {
+		errs.add("enemy.defenseIgnore", "must be between 0 and 100")
+	}
+
+	character, _ := s.Character(in.Character)
+	base := in.Base
+	if base == nil && character.Name != "" {
+		stats, err := StatsAt(character, in.Level, in.Ascended)
+		var statErrs ValidationErrors
+		if errors.As(err, &statErrs) {
+			for _, e := range statErrs {
+				if e.Field == "name" {
+					errs.add("base", "%s, give the base stats", e.Message)
+				} else {
+					errs.add(e.Field, "%s", e.Message)
+				}
+			}
+		} else {
+			base = &DamageBase{HP: stats.HP, ATK: stats.ATK, DEF: stats.DEF}
+		}
+	}
+	if in.Base != nil && (in.Level < 1 || in.Level > models.MaxCharacterLevel) {
+		errs.add("level", "must be between 1 and %d", models.MaxCharacterLevel)
+	}
+
+	multiplier, scaling := in.Skill.Multiplier, in.Skill.Scaling
+	if multiplier == 0 && character.Name != "" {
+		multiplier, scaling = skillMultiplier(character, in.Skill, &errs)
+	}
+	if multiplier < 0 {
+		errs.add("skill.multiplier", "must be positive")
+	}
+	if scaling == "" {
+		scaling = "ATK"
+	}
+	switch store.Slug(scaling) {
+	case "atk", "hp", "def":
+	default:
+		errs.add("skill.scaling", "%q is not ATK, HP or DEF", scaling)
+	}
+
+	if err := errs.err(); err != nil {
+		return DamageResult{}, err
+	}
+
+	totals := newStatTotals(s.Substats())
+	for _, stat := range build.Totals {
+		totals.add(stat)
+	}
+	for _, buff := range in.Buffs {
+		totals.add(buff)
+	}
+	totals.add(StatValue{Name: "Crit Rate", Value: BaseCritRate})
+	totals.add(StatValue{Name: "Crit Dmg", Value: BaseCritDMG})
+
+	result := DamageResult{
+		Character: build.Character,
+		Level:     in.Level,
+		Base:      *base,
+		Build:     build,
+		Totals:    totals.list(),
+		Warnings:  build.Warnings,
+	}
+	// Each step is rounded and the next one uses the rounded value, so the
+	// formulas can be followed by hand. Multipliers keep four decimals.
+	step := func(name string, value float64, format string, args ...interface{}) float64 {
+		value = round(value)
+		result.Steps = append(result.Steps, DamageStep{Name: name, Formula: fmt.Sprintf(format, args...), Value: value})
+		return value
+	}
+	factor := func(name string, value float64, format string, args ...interface{}) float64 {
+		value = math.Round(value*10000) / 10000
+		result.Steps = append(result.Steps, DamageStep{Name: name, Formula: fmt.Sprintf(format, args...), Value: value})
+		return value
+	}
+
+	stat := strings.ToUpper(store.Slug(scaling))
+	characterBase := map[string]float64{"ATK": base.ATK, "HP": base.HP, "DEF": base.DEF}[stat]
+	weaponBase := 0.0
+	if stat == "ATK" {
+		for _, weaponStat := range build.Weapon.Stats {
+			if weaponStat.Name == "ATK" {
+				weaponBase = weaponStat.Value
+			}
+		}
+	}
+	percent := totals.get(stat + "%")
+	flat := totals.get(stat) - weaponBase
+	total := step("Total "+stat, (characterBase+weaponBase)*(1+percent/100)+flat,
+		"(%g + %g) × (1 + %g%%) + %g", characterBase, weaponBase, percent, flat)
+
+	baseDamage := step("Base damage", total*multiplier/100, "%g × %g%%", total, multiplier)
+
+	elementBonus := 0.0
+	if build.Attribute != "" {
+		elementBonus = totals.get(build.Attribute + " DMG")
+	}
+	bonus := factor("DMG bonus multiplier", 1+(elementBonus+in.Skill.Bonus)/100,
+		"1 + %g%% + %g%%", elementBonus, in.Skill.Bonus)
+
+	enemyDEF := float64(EnemyDEFPerLevel*in.Enemy.Level + EnemyDEFBase)
+	attacker := float64(AttackerDEFBase + AttackerDEFPerLevel*in.Level)
+	effectiveDEF := enemyDEF * (1 - in.Enemy.DefenseIgnore/100)
+	defense := factor("DEF multiplier", attacker/(attacker+effectiveDEF),
+		"%g / (%g + %g × (1 - %g%%))", attacker, attacker, enemyDEF, in.Enemy.DefenseIgnore)
+
+	res := (resistance - in.Enemy.ResistanceReduction) / 100
+	var resMultiplier float64
+	var resFormula string
+	switch {
+	case res < 0:
+		resMultiplier, resFormula = 1-res/2, "1 - %g / 2"
+	case res < 0.8:
+		resMultiplier, resFormula = 1-res, "1 - %g"
+	default:
+		resMultiplier, resFormula = 1/(1+5*res), "1 / (1 + 5 × %g)"
+	}
+	resMultiplier = factor("RES multiplier", resMultiplier, resFormula, round(res))
+
+	nonCrit := step("Non-crit damage", baseDamage*bonus*defense*resMultiplier,
+		"%g × %g × %g × %g", baseDamage, bonus, defense, resMultiplier)
+
+	critDMG := totals.get("Crit Dmg")
+	crit := step("Crit damage", nonCrit*critDMG/100, "%g × %g%%", nonCrit, critDMG)
+
+	critRate := min(max(totals.get("Crit Rate"), 0), 100)
+	expected := step("Expected damage", nonCrit*(1-critRate/100)+crit*critRate/100,
+		"%g × (1 - %g%%) + %g × %g%%", nonCrit, critRate, crit, critRate)
+
+	result.Damage = Damage{NonCrit: nonCrit, Crit: crit, Expected: expected}
+	return result, nil
+}
+
+// skillMultiplier looks up the multiplier the skill input names in the
+// character's skills.
+func skillMultiplier(character models.Character, in DamageSkill, errs *ValidationErrors) (float64, string) {
+	if in.Name == "" {
+		errs.add("skill", "give a multiplier or the name of one of the character's skill multipliers")
+		return 0, ""
+	}
+	if in.Level == 0 {
+		in.Level = 1
+	}
+	if in.Level < 1 || in.Level > models.MaxSkillLevel {
+		errs.add("skill.level", "must be between 1 and %d", models.MaxSkillLevel)
+		return 0, ""
+	}
+
+	for _, skill := range character.Skills {
+		if in.Type != "" && store.Slug(in.Type) != store.Slug(skill.Type) && store.Slug(in.Type) != store.Slug(skill.Name) {
+			continue
+		}
+		for _, multiplier := range skill.Multipliers {
+			if store.Slug(multiplier.Name) != store.Slug(in.Name) {
+				continue
+			}
+			if in.Level > len(multiplier.Values) {
+				errs.add("skill.level", "%s is only known up to level %d", multiplier.Name, len(multiplier.Values))
+				return 0, ""
+			}
+			value := multiplier.Values[in.Level-1]
+			if multiplier.Hits > 1 {
+				value *= float64(multiplier.Hits)
+			}
+			return value, multiplier.Scaling
+		}
+	}
+	errs.add("skill.name", "%s has no skill multiplier %q", characterName(character), in.Name)
+	return 0, ""
+}
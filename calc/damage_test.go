@@ -0,0 +1,127 @@
+package calc
+
+import (
+	"testing"
+
+	"api/models"
+	"api/store"
+)
+
+func damageStore(t *testing.T) store.Store {
+	t.Helper()
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+	return store.NewMemory(store.Data{
+		Characters: []models.Character{{Name: "Lingyang", Attribute: "Glacio", Weapon: "Gauntlets", Rarity: 5}},
+		Weapons:    map[string][]models.Weapon{"Gauntlets": {{Name: "Abyss Surges", Type: "Gauntlets", Rarity: 5}}},
+		Sonatas:    m.Sonatas(),
+		Stats:      m.Stats(),
+		Substats:   m.Substats(),
+	})
+}
+
+// TestCalculateDamageSteps follows one hit through the formula by hand. The
+// weapon has no stats and there are no echoes, so only the buffs add up.
+func TestCalculateDamageSteps(t *testing.T) {
+	in := DamageInput{
+		BuildInput: BuildInput{Character: "Lingyang", Weapon: BuildWeapon{Type: "Gauntlets", Name: "Abyss Surges"}},
+		Base:       &DamageBase{HP: 10000, ATK: 400, DEF: 1000},
+		Skill:      DamageSkill{Multiplier: 200, Bonus: 10},
+		Buffs: []StatValue{
+			{Name: "ATK%", Value: 20}, {Name: "ATK", Value: 100}, {Name: "Glacio DMG", Value: 30},
+			{Name: "Crit Rate", Value: 45}, {Name: "Crit Dmg", Value: 50},
+		},
+	}
+	result, err := CalculateDamage(damageStore(t), in)
+	if err != nil {
+		t.Fatalf("CalculateDamage() = %v", err)
+	}
+
+	want := []DamageStep{
+		// 400 × 1.2 + 100
+		{Name: "Total ATK", Formula: "(400 + 0) × (1 + 20%) + 100", Value: 580},
+		// 580 × 2
+		{Name: "Base damage", Formula: "580 × 200%", Value: 1160},
+		// 1 + 0.3 + 0.1
+		{Name: "DMG bonus multiplier", Formula: "1 + 30% + 10%", Value: 1.4},
+		// The attacker is 800 + 8 × 90 and the enemy 8 × 90 + 792:
+		// 1520 / (1520 + 1512) = 0.50132
+		{Name: "DEF multiplier", Formula: "1520 / (1520 + 1512 × (1 - 0%))", Value: 0.5013},
+		// The default 10% resistance.
+		{Name: "RES multiplier", Formula: "1 - 0.1", Value: 0.9},
+		// 1160 × 1.4 × 0.5013 × 0.9 = 732.70008
+		{Name: "Non-crit damage", Formula: "1160 × 1.4 × 0.5013 × 0.9", Value: 732.7},
+		// The base 150% plus the 50% buff.
+		{Name: "Crit damage", Formula: "732.7 × 200%", Value: 1465.4},
+		// The base 5% plus the 45% buff.
+		{Name: "Expected damage", Formula: "732.7 × (1 - 50%) + 1465.4 × 50%", Value: 1099.05},
+	}
+	if len(result.Steps) != len(want) {
+		t.Fatalf("steps = %+v, want %d steps", result.Steps, len(want))
+	}
+	for i, step := range result.Steps {
+		if step != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, step, want[i])
+		}
+	}
+
+	if damage := (Damage{NonCrit: 732.7, Crit: 1465.4, Expected: 1099.05}); result.Damage != damage {
+		t.Errorf("damage = %+v, want %+v", result.Damage, damage)
+	}
+}
+
+func TestCalculateDamageResistance(t *testing.T) {
+	tests := []struct {
+		resistance, reduction float64
+		want                  float64
+	}{
+		{10, 0, 0.9},
+		{0, 0, 1},
+		// Below zero, half of the negative resistance is added.
+		{10, 30, 1.1},
+		// From 80%, the multiplier is 1 / (1 + 5 × resistance).
+		{80, 0, 0.2},
+		{100, 0, 0.1667},
+	}
+	for _, tt := range tests {
+		resistance := tt.resistance
+		in := DamageInput{
+			BuildInput: BuildInput{Character: "Lingyang", Weapon: BuildWeapon{Type: "Gauntlets", Name: "Abyss Surges"}},
+			Base:       &DamageBase{ATK: 1000},
+			Skill:      DamageSkill{Multiplier: 100},
+			Enemy:      DamageEnemy{Resistance: &resistance, ResistanceReduction: tt.reduction},
+		}
+		result, err := CalculateDamage(damageStore(t), in)
+		if err != nil {
+			t.Fatalf("CalculateDamage() = %v", err)
+		}
+		for _, step := range result.Steps {
+			if step.Name == "RES multiplier" && step.Value != tt.want {
+				t.Errorf("resistance %g%% reduced by %g%%: multiplier %g, want %g", tt.resistance, tt.reduction, step.Value, tt.want)
+			}
+		}
+	}
+}
+
+func TestCalculateDamageDefenseIgnore(t *testing.T) {
+	in := DamageInput{
+		BuildInput: BuildInput{Character: "Lingyang", Weapon: BuildWeapon{Type: "Gauntlets", Name: "Abyss Surges"}},
+		Base:       &DamageBase{ATK: 1000},
+		Level:      80,
+		Skill:      DamageSkill{Multiplier: 100},
+		Enemy:      DamageEnemy{Level: 100, DefenseIgnore: 50},
+	}
+	result, err := CalculateDamage(damageStore(t), in)
+	if err != nil {
+		t.Fatalf("CalculateDamage() = %v", err)
+	}
+	// 1440 / (1440 + 1592 × 0.5)
+	want := DamageStep{Name: "DEF multiplier", Formula: "1440 / (1440 + 1592 × (1 - 50%))", Value: 0.644}
+	for _, step := range result.Steps {
+		if step.Name == want.Name && step != want {
+			t.Errorf("step = %+v, want %+v", step, want)
+		}
+	}
+}
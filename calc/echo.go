@@ -0,0 +1,94 @@
+package calc
+
+import (
+	"math"
+
+	"api/models"
+	"api/store"
+)
+
+// MaxEchoLevel is the highest level an echo can be enhanced to. An echo
+// unlocks one substat every SubstatInterval levels.
+const (
+	MaxEchoLevel    = 25
+	SubstatInterval = 5
+)
+
+// StatValue is a named stat with its numeric value.
+type StatValue struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// echoStat returns the main stat table for echoes of the given cost.
+func echoStat(stats []models.Stat, cost int) (models.Stat, bool) {
+	for _, stat := range stats {
+		if stat.Cost == cost {
+			return stat, true
+		}
+	}
+	return models.Stat{}, false
+}
+
+// primaryStat finds the main stat called name in stat.
+func primaryStat(stat models.Stat, name string) (string, []float64, bool) {
+	for _, primary := range stat.Primary {
+		if store.Slug(primary.Name) == store.Slug(name) {
+			return primary.Name, primary.Ranks, true
+		}
+	}
+	return "", nil, false
+}
+
+// atLevel interpolates a stat at level from its ranks, which hold the value
+// at evenly spaced checkpoints from level 0 to MaxEchoLevel.
+func atLevel(ranks []float64, level int) float64 {
+	switch len(ranks) {
+	case 0:
+		return 0
+	case 1:
+		return ranks[0]
+	}
+
+	pos := float64(level) / MaxEchoLevel * float64(len(ranks)-1)
+	i := min(int(pos), len(ranks)-2)
+	frac := pos - float64(i)
+	return round(ranks[i] + (ranks[i+1]-ranks[i])*frac)
+}
+
+// MainStats returns the main and secondary stat of an echo of the given cost
+// and level.
+func MainStats(stats []models.Stat, cost int, mainStat string, level int) (StatValue, []StatValue, error) {
+	var errs ValidationErrors
+
+	if level < 0 || level > MaxEchoLevel {
+		errs.add("level", "must be between 0 and %d", MaxEchoLevel)
+	}
+	stat, ok := echoStat(stats, cost)
+	if !ok {
+		errs.add("cost", "no echoes of cost %d", cost)
+		return StatValue{}, nil, errs
+	}
+	name, ranks, ok := primaryStat(stat, mainStat)
+	if !ok {
+		var names []string
+		for _, primary := range stat.Primary {
+			names = append(names, primary.Name)
+		}
+		errs.add("mainStat", "%q is not a main stat of cost %d echoes, expected one of %v", mainStat, cost, names)
+	}
+	if err := errs.err(); err != nil {
+		return StatValue{}, nil, err
+	}
+
+	main := StatValue{Name: name, Value: atLevel(ranks, level)}
+	var secondary []StatValue
+	for _, s := range stat.Secondary {
+		secondary = append(secondary, StatValue{Name: s.Name, Value: atLevel(s.Ranks, level)})
+	}
+	return main, secondary, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,307 @@
+package calc
+
+import (
+	"cmp"
+	"container/heap"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"api/models"
+	"api/store"
+)
+
+// Optimizer limits. MaxOptimizeResults caps the combinations returned and
+// maxCandidates the echoes considered, which keeps the search below a few
+// hundred thousand combinations.
+const (
+	DefaultOptimizeResults = 10
+	MaxOptimizeResults     = 100
+	maxCandidates          = 40
+)
+
+// OptimizeInput asks for five-echo sets that complete a sonata's 5-piece
+// effect. Layouts lists preferred cost layouts such as "4-3-3-1-1", most
+// preferred first.
+type OptimizeInput struct {
+	Sonata  string   `json:"sonata"`
+	Layouts []string `json:"layouts"`
+	MaxCost int      `json:"maxCost"`
+	Exclude []string `json:"exclude"`
+	Limit   int      `json:"limit"`
+}
+
+// OptimizedEcho is one echo of a combination.
+type OptimizedEcho struct {
+	Name    string   `json:"name"`
+	Cost    int      `json:"cost"`
+	Sonatas []string `json:"sonatas"`
+}
+
+// Combination is a set of echoes that completes the sonata. Chance is the
+// probability that every echo rolls the sonata when its sonata is random.
+type Combination struct {
+	Rank      int             `json:"rank"`
+	Layout    string          `json:"layout"`
+	Cost      int             `json:"cost"`
+	Preferred bool            `json:"preferred"`
+	Chance    float64         `json:"chance"`
+	Echoes    []OptimizedEcho `json:"echoes"`
+}
+
+// OptimizeResult lists the best combinations out of Total valid ones.
+type OptimizeResult struct {
+	Sonata       string        `json:"sonata"`
+	MaxCost      int           `json:"maxCost"`
+	Layouts      []string      `json:"layouts"`
+	Candidates   int           `json:"candidates"`
+	Total        int           `json:"total"`
+	Combinations []Combination `json:"combinations"`
+}
+
+// SonataEchoes returns the echoes that can roll sonata.
+func SonataEchoes(s store.Store, sonata models.Sonata) []models.Echo {
+	var echoes []models.Echo
+	for echo := range s.EachEcho() {
+		if canRoll(echo, sonata.Name) {
+			echoes = append(echoes, echo)
+		}
+	}
+	return echoes
+}
+
+func canRoll(echo models.Echo, sonata string) bool {
+	for _, name := range echo.SonataEffects {
+		if store.Slug(name) == store.Slug(sonata) {
+			return true
+		}
+	}
+	return false
+}
+
+// Optimize finds combinations of FivePiece different echoes that can all roll
+// the requested sonata and fit in the cost budget. Combinations are ranked by
+// preferred layout, then by total cost, since higher cost echoes carry more
+// stats, and then by the chance of rolling the sonata on every echo.
+func Optimize(s store.Store, in OptimizeInput) (OptimizeResult, error) {
+	s = s.Snapshot()
+	var errs ValidationErrors
+
+	sonata, ok := s.Sonata(in.Sonata)
+	if !ok {
+		errs.add("sonata", "unknown sonata %q", in.Sonata)
+	}
+
+	if in.MaxCost == 0 {
+		in.MaxCost = MaxEchoCost
+	}
+	if in.MaxCost < FivePiece || in.MaxCost > MaxEchoCost {
+		errs.add("maxCost", "must be between %d and %d", FivePiece, MaxEchoCost)
+	}
+
+	if in.Limit == 0 {
+		in.Limit = DefaultOptimizeResults
+	}
+	if in.Limit < 1 || in.Limit > MaxOptimizeResults {
+		errs.add("limit", "must be between 1 and %d", MaxOptimizeResults)
+	}
+
+	layouts := make([]string, 0, len(in.Layouts))
+	for i, text := range in.Layouts {
+		layout, err := parseLayout(text)
+		if err != nil {
+			errs.add(fmt.Sprintf("layouts[%d]", i), "%v", err)
+			continue
+		}
+		if cost := sum(layout); cost > in.MaxCost {
+			errs.add(fmt.Sprintf("layouts[%d]", i), "%s costs %d, more than %d", formatLayout(layout), cost, in.MaxCost)
+			continue
+		}
+		layouts = append(layouts, formatLayout(layout))
+	}
+
+	excluded := make(map[string]bool)
+	for i, name := range in.Exclude {
+		echo, ok := s.Echo(name)
+		if !ok {
+			errs.add(fmt.Sprintf("exclude[%d]", i), "unknown echo %q", name)
+			continue
+		}
+		excluded[echo.Name] = true
+	}
+
+	if err := errs.err(); err != nil {
+		return OptimizeResult{}, err
+	}
+
+	var candidates []models.Echo
+	for _, echo := range SonataEchoes(s, sonata) {
+		if !excluded[echo.Name] {
+			candidates = append(candidates, echo)
+		}
+	}
+	// Fewer alternative sonatas means a better chance of rolling this one, so
+	// those echoes are kept when there are too many candidates.
+	slices.SortStableFunc(candidates, func(a, b models.Echo) int {
+		return cmp.Or(cmp.Compare(len(a.SonataEffects), len(b.SonataEffects)), cmp.Compare(b.Cost, a.Cost))
+	})
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+
+	result := OptimizeResult{
+		Sonata:       sonata.Name,
+		MaxCost:      in.MaxCost,
+		Layouts:      layouts,
+		Candidates:   len(candidates),
+		Combinations: []Combination{},
+	}
+
+	preference := make(map[string]int, len(layouts))
+	for i, layout := range layouts {
+		if _, ok := preference[layout]; !ok {
+			preference[layout] = i
+		}
+	}
+
+	// Only the best Limit combinations are kept while enumerating, in a heap
+	// with the worst of them on top. Ties go to the combination found first.
+	top := &combinationHeap{preference: preference}
+	picked := make([]models.Echo, 0, FivePiece)
+	var walk func(start, cost int)
+	walk = func(start, cost int) {
+		if len(picked) == FivePiece {
+			result.Total++
+			layout, chance := combinationKey(picked)
+			c := rankedCombination{Combination: Combination{Layout: layout, Cost: cost, Chance: chance}, seq: result.Total}
+			if top.Len() == in.Limit && top.compare(c, top.items[0]) >= 0 {
+				return
+			}
+			c.Combination = newCombination(picked, cost, preference)
+			heap.Push(top, c)
+			if top.Len() > in.Limit {
+				heap.Pop(top)
+			}
+			return
+		}
+		for i := start; i < len(candidates); i++ {
+			if cost+candidates[i].Cost > in.MaxCost {
+				continue
+			}
+			picked = append(picked, candidates[i])
+			walk(i+1, cost+candidates[i].Cost)
+			picked = picked[:len(picked)-1]
+		}
+	}
+	walk(0, 0)
+
+	slices.SortFunc(top.items, top.compare)
+	for i, c := range top.items {
+		c.Rank = i + 1
+		result.Combinations = append(result.Combinations, c.Combination)
+	}
+	return result, nil
+}
+
+// rankedCombination is a combination with the order it was found in.
+type rankedCombination struct {
+	Combination
+	seq int
+}
+
+// combinationHeap is a heap of combinations with the worst ranked on top.
+type combinationHeap struct {
+	items      []rankedCombination
+	preference map[string]int
+}
+
+// compare orders combinations best first: by preferred layout, then highest
+// cost, then best chance, then the order they were found in.
+func (h *combinationHeap) compare(a, b rankedCombination) int {
+	return cmp.Or(
+		cmp.Compare(layoutRank(a.Combination, h.preference), layoutRank(b.Combination, h.preference)),
+		cmp.Compare(b.Cost, a.Cost),
+		cmp.Compare(b.Chance, a.Chance),
+		cmp.Compare(a.seq, b.seq),
+	)
+}
+
+func (h *combinationHeap) Len() int           { return len(h.items) }
+func (h *combinationHeap) Less(i, j int) bool { return h.compare(h.items[i], h.items[j]) > 0 }
+func (h *combinationHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *combinationHeap) Push(x any)         { h.items = append(h.items, x.(rankedCombination)) }
+func (h *combinationHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// combinationKey returns the layout of the picked echoes and the chance, in
+// percent, that they all roll the sonata.
+func combinationKey(picked []models.Echo) (string, float64) {
+	costs := make([]int, 0, len(picked))
+	chance := 1.0
+	for _, echo := range picked {
+		costs = append(costs, echo.Cost)
+		chance /= float64(len(echo.SonataEffects))
+	}
+	slices.SortFunc(costs, func(a, b int) int { return cmp.Compare(b, a) })
+	return formatLayout(costs), round(chance * 100)
+}
+
+func newCombination(picked []models.Echo, cost int, preference map[string]int) Combination {
+	combination := Combination{Cost: cost}
+	combination.Layout, combination.Chance = combinationKey(picked)
+	for _, echo := range picked {
+		combination.Echoes = append(combination.Echoes, OptimizedEcho{Name: echo.Name, Cost: echo.Cost, Sonatas: echo.SonataEffects})
+	}
+	slices.SortFunc(combination.Echoes, func(a, b OptimizedEcho) int {
+		return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(a.Name, b.Name))
+	})
+	_, combination.Preferred = preference[combination.Layout]
+	return combination
+}
+
+// layoutRank orders preferred layouts by preference, ahead of the others.
+func layoutRank(c Combination, preference map[string]int) int {
+	if i, ok := preference[c.Layout]; ok {
+		return i
+	}
+	return len(preference)
+}
+
+// parseLayout reads a layout such as "4-3-3-1-1" into its costs, sorted from
+// highest to lowest.
+func parseLayout(text string) ([]int, error) {
+	parts := strings.Split(text, "-")
+	if len(parts) != FivePiece {
+		return nil, fmt.Errorf("%q must list %d costs, like 4-3-3-1-1", text, FivePiece)
+	}
+	costs := make([]int, 0, len(parts))
+	for _, part := range parts {
+		cost, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || (cost != 1 && cost != 3 && cost != 4) {
+			return nil, fmt.Errorf("%q is not an echo cost, expected 1, 3 or 4", part)
+		}
+		costs = append(costs, cost)
+	}
+	slices.SortFunc(costs, func(a, b int) int { return cmp.Compare(b, a) })
+	return costs, nil
+}
+
+func formatLayout(costs []int) string {
+	parts := make([]string, len(costs))
+	for i, cost := range costs {
+		parts[i] = strconv.Itoa(cost)
+	}
+	return strings.Join(parts, "-")
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
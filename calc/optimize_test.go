@@ -0,0 +1,112 @@
+package calc
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"testing"
+
+	"api/models"
+	"api/store"
+)
+
+func optimizeStore() store.Store {
+	var echoes []models.Echo
+	add := func(cost int, sonatas ...string) {
+		echoes = append(echoes, models.Echo{Name: fmt.Sprintf("Echo %d", len(echoes)+1), Cost: cost, SonataEffects: sonatas})
+	}
+	add(4, "Molten Rift")
+	add(4, "Molten Rift", "Void Thunder")
+	add(3, "Molten Rift")
+	add(3, "Molten Rift", "Freezing Frost")
+	add(3, "Molten Rift", "Freezing Frost", "Void Thunder")
+	add(1, "Molten Rift")
+	add(1, "Molten Rift", "Void Thunder")
+	add(1, "Molten Rift", "Freezing Frost")
+	add(1, "Molten Rift", "Freezing Frost", "Void Thunder")
+	add(4, "Void Thunder")
+	return store.NewMemory(store.Data{
+		Echoes:  echoes,
+		Sonatas: []models.Sonata{{Name: "Molten Rift"}, {Name: "Void Thunder"}, {Name: "Freezing Frost"}},
+	})
+}
+
+func TestOptimizeRanking(t *testing.T) {
+	result, err := Optimize(optimizeStore(), OptimizeInput{Sonata: "molten-rift", Layouts: []string{"3-3-3-1-1"}, Limit: MaxOptimizeResults})
+	if err != nil {
+		t.Fatalf("Optimize() = %v", err)
+	}
+	// Of the 126 sets of 5 of the 9 echoes that roll Molten Rift, 31 with both 4-cost
+	// echoes and 8 with a 4-cost and every 3-cost one cost more than 12.
+	if result.Candidates != 9 || result.Total != 126-31-8 {
+		t.Fatalf("Optimize() candidates %d, total %d, want 9 and %d", result.Candidates, result.Total, 126-31-8)
+	}
+	if len(result.Combinations) != result.Total {
+		t.Fatalf("Optimize() returned %d combinations, want all %d", len(result.Combinations), result.Total)
+	}
+
+	first := result.Combinations[0]
+	if first.Layout != "3-3-3-1-1" || !first.Preferred || first.Chance != round(100.0/(2*3*2)) {
+		t.Errorf("first combination = %+v, want the preferred layout with its best chance", first)
+	}
+	for i, c := range result.Combinations {
+		if c.Rank != i+1 {
+			t.Errorf("combinations[%d].Rank = %d", i, c.Rank)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := result.Combinations[i-1]
+		order := cmp.Or(
+			cmp.Compare(notPreferred(prev), notPreferred(c)),
+			cmp.Compare(c.Cost, prev.Cost),
+			cmp.Compare(c.Chance, prev.Chance),
+		)
+		if order > 0 {
+			t.Errorf("combinations[%d] %+v ranks ahead of %+v", i-1, prev, c)
+		}
+	}
+}
+
+func TestOptimizeLimit(t *testing.T) {
+	s := optimizeStore()
+	all, err := Optimize(s, OptimizeInput{Sonata: "molten-rift", Limit: MaxOptimizeResults})
+	if err != nil {
+		t.Fatalf("Optimize() = %v", err)
+	}
+
+	for _, limit := range []int{1, 3, 10} {
+		result, err := Optimize(s, OptimizeInput{Sonata: "molten-rift", Limit: limit})
+		if err != nil {
+			t.Fatalf("Optimize(limit %d) = %v", limit, err)
+		}
+		if result.Total != all.Total {
+			t.Errorf("Optimize(limit %d) total = %d, want %d", limit, result.Total, all.Total)
+		}
+		if !slices.EqualFunc(result.Combinations, all.Combinations[:limit], sameCombination) {
+			t.Errorf("Optimize(limit %d) = %+v, want the first %d of %+v", limit, result.Combinations, limit, all.Combinations[:limit])
+		}
+	}
+
+	result, err := Optimize(s, OptimizeInput{Sonata: "molten-rift"})
+	if err != nil || len(result.Combinations) != DefaultOptimizeResults {
+		t.Errorf("Optimize() without a limit returned %d combinations, %v, want %d", len(result.Combinations), err, DefaultOptimizeResults)
+	}
+	for _, limit := range []int{-1, MaxOptimizeResults + 1} {
+		if _, err := Optimize(s, OptimizeInput{Sonata: "molten-rift", Limit: limit}); err == nil {
+			t.Errorf("Optimize(limit %d) succeeded", limit)
+		}
+	}
+}
+
+func sameCombination(a, b Combination) bool {
+	return a.Rank == b.Rank && a.Layout == b.Layout && a.Cost == b.Cost && a.Chance == b.Chance &&
+		slices.EqualFunc(a.Echoes, b.Echoes, func(x, y OptimizedEcho) bool { return x.Name == y.Name })
+}
+
+func notPreferred(c Combination) int {
+	if c.Preferred {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,251 @@
+package calc
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"api/models"
+	"api/store"
+)
+
+// PlanRange is a current and a target level.
+type PlanRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// PlanCharacter is a character to level from From to To. Ascended tells that
+// the character is already ascended at a From that is a level cap. Each of
+// Skills is one skill to level.
+type PlanCharacter struct {
+	Name     string      `json:"name"`
+	From     int         `json:"from"`
+	To       int         `json:"to"`
+	Ascended bool        `json:"ascended"`
+	Skills   []PlanRange `json:"skills"`
+}
+
+// PlanWeapon is a weapon to level from From to To.
+type PlanWeapon struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+	Ascended bool   `json:"ascended"`
+}
+
+// PlanInput lists the characters and weapons to plan upgrades for.
+type PlanInput struct {
+	Characters []PlanCharacter `json:"characters"`
+	Weapons    []PlanWeapon    `json:"weapons"`
+}
+
+// PlannedMaterial is the total amount of a material needed.
+type PlannedMaterial struct {
+	Name     string   `json:"name"`
+	Amount   int      `json:"amount"`
+	Category string   `json:"category,omitempty"`
+	Rarity   int      `json:"rarity,omitempty"`
+	Sources  []string `json:"sources,omitempty"`
+}
+
+// PlanItem is the cost of upgrading one character or weapon.
+type PlanItem struct {
+	Kind      string                `json:"kind"`
+	Name      string                `json:"name"`
+	EXP       int                   `json:"exp"`
+	Credits   int                   `json:"credits"`
+	Materials []models.MaterialCost `json:"materials"`
+}
+
+// PlanResult adds up the cost of every upgrade. EXP is split between
+// character and weapon EXP, which come from different items.
+type PlanResult struct {
+	Credits   int               `json:"credits"`
+	EXP       PlanEXP           `json:"exp"`
+	Materials []PlannedMaterial `json:"materials"`
+	Items     []PlanItem        `json:"items"`
+	Warnings  []string          `json:"warnings,omitempty"`
+}
+
+// PlanEXP is the EXP needed for characters and for weapons.
+type PlanEXP struct {
+	Character int `json:"character"`
+	Weapon    int `json:"weapon"`
+}
+
+// Plan adds up the EXP, shell credits and materials needed to bring every
+// character and weapon in input from its current to its target level.
+// Costs missing from the data are left out and reported as warnings.
+func Plan(s store.Store, in PlanInput) (PlanResult, error) {
+	s = s.Snapshot()
+	var errs ValidationErrors
+	result := PlanResult{Materials: []PlannedMaterial{}, Items: []PlanItem{}}
+	costs := s.Costs()
+
+	for i, planned := range in.Characters {
+		field := fmt.Sprintf("characters[%d]", i)
+		character, ok := s.Character(planned.Name)
+		if !ok {
+			errs.add(field+".name", "unknown character %q", planned.Name)
+			continue
+		}
+		name := characterName(character)
+		item := PlanItem{Kind: "character", Name: name, Materials: []models.MaterialCost{}}
+
+		caps := levelCaps(character)
+		levels := PlanRange{From: planned.From, To: planned.To}
+		if !checkRange(&levels, caps[len(caps)-1], field, &errs) {
+			continue
+		}
+		result.levelUps(&item, costs.Character, levels)
+		for _, phase := range phasesBetween(caps, levels, planned.Ascended) {
+			if phase > len(character.Ascension) {
+				result.warn("ascension phase %d of %s is not in the data", phase, name)
+				continue
+			}
+			cost := character.Ascension[phase-1]
+			item.add(cost.Credits, cost.Materials)
+		}
+
+		for j, skill := range planned.Skills {
+			skillField := fmt.Sprintf("%s.skills[%d]", field, j)
+			if !checkRange(&skill, models.MaxSkillLevel, skillField, &errs) {
+				continue
+			}
+			for level := skill.From + 1; level <= skill.To; level++ {
+				k := slices.IndexFunc(character.Forte, func(cost models.UpgradeCost) bool { return cost.Level == level })
+				if k < 0 {
+					result.warn("skill level %d costs of %s are not in the data", level, name)
+					continue
+				}
+				item.add(character.Forte[k].Credits, character.Forte[k].Materials)
+			}
+		}
+
+		result.EXP.Character += item.EXP
+		result.Items = append(result.Items, item)
+	}
+
+	for i, planned := range in.Weapons {
+		field := fmt.Sprintf("weapons[%d]", i)
+		weapon, ok := s.Weapon(planned.Type, planned.Name)
+		if !ok {
+			errs.add(field, "unknown weapon %q of type %q", planned.Name, planned.Type)
+			continue
+		}
+		item := PlanItem{Kind: "weapon", Name: weapon.Name, Materials: []models.MaterialCost{}}
+
+		levels := PlanRange{From: planned.From, To: planned.To}
+		if !checkRange(&levels, models.MaxCharacterLevel, field, &errs) {
+			continue
+		}
+		result.levelUps(&item, costs.Weapon, levels)
+		for _, phase := range phasesBetween(LevelCaps[:], levels, planned.Ascended) {
+			if phase > len(weapon.Ascension) {
+				result.warn("ascension phase %d of %s is not in the data", phase, weapon.Name)
+				continue
+			}
+			cost := weapon.Ascension[phase-1]
+			item.add(cost.Credits, cost.Materials)
+		}
+
+		result.EXP.Weapon += item.EXP
+		result.Items = append(result.Items, item)
+	}
+
+	if err := errs.err(); err != nil {
+		return PlanResult{}, err
+	}
+
+	totals := make(map[string]int)
+	for _, item := range result.Items {
+		result.Credits += item.Credits
+		for _, material := range item.Materials {
+			totals[material.Name] += material.Amount
+		}
+	}
+	for name, amount := range totals {
+		planned := PlannedMaterial{Name: name, Amount: amount}
+		if material, ok := s.Material(name); ok {
+			planned.Name = material.Name
+			planned.Category = material.Category
+			planned.Rarity = material.Rarity
+			planned.Sources = material.Sources
+		}
+		result.Materials = append(result.Materials, planned)
+	}
+	slices.SortFunc(result.Materials, func(a, b PlannedMaterial) int {
+		return cmp.Or(cmp.Compare(a.Category, b.Category), cmp.Compare(a.Rarity, b.Rarity), cmp.Compare(a.Name, b.Name))
+	})
+	return result, nil
+}
+
+// checkRange defaults a level range to the full range up to maxLevel and
+// reports it when it is out of bounds.
+func checkRange(r *PlanRange, maxLevel int, field string, errs *ValidationErrors) bool {
+	if r.From == 0 {
+		r.From = 1
+	}
+	if r.To == 0 {
+		r.To = maxLevel
+	}
+	switch {
+	case r.From < 1 || r.To > maxLevel:
+		errs.add(field, "levels %d to %d are not within 1-%d", r.From, r.To, maxLevel)
+		return false
+	case r.From > r.To:
+		errs.add(field, "current level %d is above the target %d", r.From, r.To)
+		return false
+	}
+	return true
+}
+
+// phasesBetween returns the ascension phases passed when levelling through
+// levels: every phase whose preceding cap is reached before the target.
+func phasesBetween(caps []int, levels PlanRange, ascended bool) []int {
+	var phases []int
+	for phase := 1; phase < len(caps); phase++ {
+		limit := caps[phase-1]
+		if limit < levels.From || limit >= levels.To {
+			continue
+		}
+		if limit == levels.From && ascended {
+			continue
+		}
+		phases = append(phases, phase)
+	}
+	return phases
+}
+
+// levelUps adds the EXP and credits of every level up in levels to item.
+func (r *PlanResult) levelUps(item *PlanItem, costs models.LevelCosts, levels PlanRange) {
+	for level := levels.From; level < levels.To; level++ {
+		if level > len(costs.EXP) || level > len(costs.Credits) {
+			r.warn("%s level up costs from level %d are not in the data", item.Kind, level)
+			return
+		}
+		item.EXP += costs.EXP[level-1]
+		item.Credits += costs.Credits[level-1]
+	}
+}
+
+func (item *PlanItem) add(credits int, materials []models.MaterialCost) {
+	item.Credits += credits
+	for _, material := range materials {
+		i := slices.IndexFunc(item.Materials, func(m models.MaterialCost) bool { return m.Name == material.Name })
+		if i < 0 {
+			item.Materials = append(item.Materials, material)
+			continue
+		}
+		item.Materials[i].Amount += material.Amount
+	}
+}
+
+func (r *PlanResult) warn(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if !slices.Contains(r.Warnings, message) {
+		r.Warnings = append(r.Warnings, message)
+	}
+}
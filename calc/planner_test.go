@@ -0,0 +1,39 @@
+package calc
+
+import (
+	"testing"
+
+	"api/store"
+)
+
+// TestPlanData plans a character and a weapon from scratch against the
+// shipped data, which should have every cost the planner needs.
+func TestPlanData(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+
+	result, err := Plan(m, PlanInput{
+		Characters: []PlanCharacter{{Name: "Jiyan", Skills: []PlanRange{{}, {From: 4, To: 6}}}},
+		Weapons:    []PlanWeapon{{Type: "Broadblade", Name: "Verdant Summit", From: 20, Ascended: true}},
+	})
+	if err != nil {
+		t.Fatalf("Plan() = %v", err)
+	}
+	if len(result.Warnings) > 0 {
+		t.Errorf("Plan() warned %q", result.Warnings)
+	}
+	if result.Credits == 0 || result.EXP.Character == 0 || result.EXP.Weapon == 0 || len(result.Materials) == 0 {
+		t.Errorf("Plan() = %+v, want credits, EXP and materials", result)
+	}
+
+	// Levelling from 20 after ascending skips the first ascension.
+	for _, item := range result.Items {
+		for _, material := range item.Materials {
+			if item.Kind == "weapon" && material.Name == "LF Howler Core" {
+				t.Errorf("weapon plan includes the phase 1 ascension: %+v", item.Materials)
+			}
+		}
+	}
+}
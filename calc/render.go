@@ -0,0 +1,94 @@
+package calc
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"api/models"
+)
+
+// MaxRefinement is the highest refinement level of a weapon.
+const MaxRefinement = 5
+
+var placeholderPattern = regexp.MustCompile(`\{(\d+)\}`)
+
+// render replaces every {n} placeholder in text with value(n). Placeholders
+// without a value are left as they are.
+func render(text string, value func(n int) (string, bool)) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		n, _ := strconv.Atoi(match[1 : len(match)-1])
+		if v, ok := value(n); ok {
+			return v
+		}
+		return match
+	})
+}
+
+// RenderWeaponSkill returns the weapon's skill description with the values of
+// the given refinement level substituted.
+func RenderWeaponSkill(weapon models.Weapon, refinement int) (string, error) {
+	if refinement < 1 || refinement > MaxRefinement {
+		return "", ValidationErrors{{Field: "refinement", Message: fmt.Sprintf("must be between 1 and %d", MaxRefinement)}}
+	}
+
+	return render(weapon.Skill.Description, func(n int) (string, bool) {
+		if n >= len(weapon.Skill.Ranks) || refinement > len(weapon.Skill.Ranks[n]) {
+			return "", false
+		}
+		return weapon.Skill.Ranks[n][refinement-1], true
+	}), nil
+}
+
+// MissingWeaponValues returns the {n} placeholders of the weapon's skill
+// description that have no value at the given refinement, which
+// RenderWeaponSkill leaves in place.
+func MissingWeaponValues(weapon models.Weapon, refinement int) []int {
+	var missing []int
+	for _, match := range placeholderPattern.FindAllStringSubmatch(weapon.Skill.Description, -1) {
+		n, _ := strconv.Atoi(match[1])
+		if n >= len(weapon.Skill.Ranks) || refinement > len(weapon.Skill.Ranks[n]) {
+			if !slices.Contains(missing, n) {
+				missing = append(missing, n)
+			}
+		}
+	}
+	return missing
+}
+
+// RenderEcho returns the echo's description with the values of the given rank
+// substituted.
+func RenderEcho(echo models.Echo, rank int) (string, error) {
+	for _, r := range echo.Ranks {
+		if r.Rank == rank {
+			return renderEchoRank(echo.Description, r), nil
+		}
+	}
+
+	if len(echo.Ranks) == 0 {
+		return "", ValidationErrors{{Field: "rank", Message: "this echo has no ranks"}}
+	}
+	first, last := echo.Ranks[0].Rank, echo.Ranks[len(echo.Ranks)-1].Rank
+	return "", ValidationErrors{{Field: "rank", Message: fmt.Sprintf("must be between %d and %d", first, last)}}
+}
+
+// RenderEchoRanks returns a copy of the echo's ranks with each description
+// rendered for its rank.
+func RenderEchoRanks(echo models.Echo) models.EchoRanks {
+	ranks := make(models.EchoRanks, len(echo.Ranks))
+	for i, r := range echo.Ranks {
+		r.Description = renderEchoRank(echo.Description, r)
+		ranks[i] = r
+	}
+	return ranks
+}
+
+func renderEchoRank(description string, rank models.EchoRank) string {
+	return render(description, func(n int) (string, bool) {
+		if n >= len(rank.Values) {
+			return "", false
+		}
+		return strconv.FormatFloat(rank.Values[n], 'f', -1, 64), true
+	})
+}
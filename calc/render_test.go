@@ -0,0 +1,58 @@
+package calc
+
+import (
+	"slices"
+	"testing"
+
+	"api/models"
+)
+
+func TestRenderWeaponSkill(t *testing.T) {
+	var weapon models.Weapon
+	weapon.Skill.Description = "ATK is increased by {0}. Every {1}s, gain {0} more."
+	weapon.Skill.Ranks = []models.SkillRank{
+		{"12%", "15%", "18%", "21%", "24%"},
+		{"3", "3", "2.5", "2.5"},
+	}
+
+	tests := []struct {
+		refinement int
+		want       string
+		missing    []int
+	}{
+		{1, "ATK is increased by 12%. Every 3s, gain 12% more.", nil},
+		{3, "ATK is increased by 18%. Every 2.5s, gain 18% more.", nil},
+		{5, "ATK is increased by 24%. Every {1}s, gain 24% more.", []int{1}},
+	}
+	for _, tt := range tests {
+		got, err := RenderWeaponSkill(weapon, tt.refinement)
+		if err != nil || got != tt.want {
+			t.Errorf("RenderWeaponSkill(%d) = %q, %v, want %q", tt.refinement, got, err, tt.want)
+		}
+		if missing := MissingWeaponValues(weapon, tt.refinement); !slices.Equal(missing, tt.missing) {
+			t.Errorf("MissingWeaponValues(%d) = %v, want %v", tt.refinement, missing, tt.missing)
+		}
+	}
+
+	for _, refinement := range []int{0, MaxRefinement + 1} {
+		if _, err := RenderWeaponSkill(weapon, refinement); err == nil {
+			t.Errorf("RenderWeaponSkill(%d) succeeded", refinement)
+		}
+	}
+}
+
+func TestRenderEcho(t *testing.T) {
+	echo := models.Echo{
+		Description: "Deals {0}% DMG, then {1}% DMG.",
+		Ranks:       models.EchoRanks{{Rank: 2, Values: []float64{34.5, 69}}, {Rank: 3, Values: []float64{39}}},
+	}
+	if got, err := RenderEcho(echo, 2); err != nil || got != "Deals 34.5% DMG, then 69% DMG." {
+		t.Errorf("RenderEcho(2) = %q, %v", got, err)
+	}
+	if got, err := RenderEcho(echo, 3); err != nil || got != "Deals 39% DMG, then {1}% DMG." {
+		t.Errorf("RenderEcho(3) = %q, %v", got, err)
+	}
+	if _, err := RenderEcho(echo, 5); err == nil {
+		t.Error("RenderEcho(5) succeeded past the last rank")
+	}
+}
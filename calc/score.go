@@ -0,0 +1,197 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"api/models"
+	"api/store"
+)
+
+// MaxSubstats is the number of substats a fully enhanced echo has.
+const MaxSubstats = MaxEchoLevel / SubstatInterval
+
+// ScoreProfiles are the built-in substat weightings. Substats missing from a
+// profile weigh nothing.
+var ScoreProfiles = map[string]map[string]float64{
+	"default": {
+		"Crit Rate": 1, "Crit Dmg": 1, "ATK%": 1, "HP%": 1, "DEF%": 1, "Energy Regen": 1,
+		"Basic Attack Dmg Bonus": 1, "Heavy Attack Dmg Bonus": 1, "Resonance Skill Dmg Bonus": 1,
+		"Resonance Liberation Dmg Bonus": 1, "ATK": 0.5, "HP": 0.5, "DEF": 0.5,
+	},
+	"crit": {
+		"Crit Rate": 1, "Crit Dmg": 1, "ATK%": 0.75, "Basic Attack Dmg Bonus": 0.5,
+		"Heavy Attack Dmg Bonus": 0.5, "Resonance Skill Dmg Bonus": 0.5,
+		"Resonance Liberation Dmg Bonus": 0.5, "Energy Regen": 0.25, "ATK": 0.25,
+	},
+	"support": {
+		"Energy Regen": 1, "HP%": 0.75, "ATK%": 0.5, "DEF%": 0.5, "Crit Rate": 0.25,
+		"Crit Dmg": 0.25, "HP": 0.25,
+	},
+}
+
+// ScoreInput is an echo to score. Weights adjust or extend the chosen profile.
+type ScoreInput struct {
+	Cost     int                `json:"cost"`
+	MainStat string             `json:"mainStat"`
+	Substats []StatValue        `json:"substats"`
+	Profile  string             `json:"profile"`
+	Weights  map[string]float64 `json:"weights"`
+}
+
+// SubstatScore rates one substat roll. Percentile is where the value falls
+// within the substat's min/max range, from 0 to 100.
+type SubstatScore struct {
+	Name       string  `json:"name"`
+	Value      float64 `json:"value"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Percentile float64 `json:"percentile"`
+	Weight     float64 `json:"weight"`
+}
+
+// ScoreResult rates a whole echo. Score is the weighted sum of substat
+// percentiles relative to the best echo possible under the same weights, so
+// five maximum rolls of the highest weighted substats score 100.
+type ScoreResult struct {
+	Cost     int                `json:"cost"`
+	MainStat StatValue          `json:"mainStat"`
+	Profile  string             `json:"profile"`
+	Weights  map[string]float64 `json:"weights"`
+	Substats []SubstatScore     `json:"substats"`
+	Score    float64            `json:"score"`
+}
+
+// Score validates an echo against the stat tables and rates its substats.
+func Score(stats []models.Stat, substats []models.Substat, in ScoreInput) (ScoreResult, error) {
+	var errs ValidationErrors
+
+	if in.Profile == "" {
+		in.Profile = "default"
+	}
+	profile, ok := ScoreProfiles[in.Profile]
+	if !ok {
+		errs.add("profile", "unknown profile %q, expected one of %v", in.Profile, profileNames())
+	}
+
+	bySlug := substatIndex(substats)
+
+	weights := make(map[string]float64, len(substats))
+	for name, weight := range profile {
+		weights[name] = weight
+	}
+	for name, weight := range in.Weights {
+		substat, ok := bySlug[store.Slug(name)]
+		if !ok {
+			errs.add("weights."+name, "unknown substat")
+			continue
+		}
+		if weight < 0 {
+			errs.add("weights."+name, "must not be negative")
+			continue
+		}
+		weights[substat.Name] = weight
+	}
+
+	main, _, err := MainStats(stats, in.Cost, in.MainStat, MaxEchoLevel)
+	var mainErrs ValidationErrors
+	if errors.As(err, &mainErrs) {
+		errs = append(errs, mainErrs...)
+	}
+
+	if len(in.Substats) > MaxSubstats {
+		errs.add("substats", "an echo has at most %d substats", MaxSubstats)
+	}
+
+	result := ScoreResult{Cost: in.Cost, MainStat: main, Profile: in.Profile, Weights: weights}
+
+	seen := make(map[string]bool)
+	var total float64
+	for i, roll := range in.Substats {
+		substat, ok := checkRoll(bySlug, roll, fmt.Sprintf("substats[%d]", i), seen, &errs)
+		if !ok {
+			continue
+		}
+
+		percentile := 100.0
+		if substat.Max > substat.Min {
+			percentile = (roll.Value - substat.Min) / (substat.Max - substat.Min) * 100
+		}
+		weight := weights[substat.Name]
+		total += weight * percentile
+
+		result.Substats = append(result.Substats, SubstatScore{
+			Name:       substat.Name,
+			Value:      roll.Value,
+			Min:        substat.Min,
+			Max:        substat.Max,
+			Percentile: round(percentile),
+			Weight:     weight,
+		})
+	}
+
+	if err := errs.err(); err != nil {
+		return ScoreResult{}, err
+	}
+
+	if best := bestWeights(weights); best > 0 {
+		result.Score = round(total / best)
+	}
+	return result, nil
+}
+
+// bestWeights is the sum of the MaxSubstats largest weights.
+func bestWeights(weights map[string]float64) float64 {
+	values := make([]float64, 0, len(weights))
+	for _, w := range weights {
+		values = append(values, w)
+	}
+	slices.Sort(values)
+	slices.Reverse(values)
+
+	var sum float64
+	for _, w := range values[:min(MaxSubstats, len(values))] {
+		sum += w
+	}
+	return sum
+}
+
+func profileNames() []string {
+	names := make([]string, 0, len(ScoreProfiles))
+	for name := range ScoreProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func substatIndex(substats []models.Substat) map[string]models.Substat {
+	bySlug := make(map[string]models.Substat, len(substats))
+	for _, substat := range substats {
+		bySlug[store.Slug(substat.Name)] = substat
+	}
+	return bySlug
+}
+
+// checkRoll validates one substat roll at field: the substat must exist, not
+// repeat one in seen, and have a value within its range.
+func checkRoll(bySlug map[string]models.Substat, roll StatValue, field string, seen map[string]bool, errs *ValidationErrors) (models.Substat, bool) {
+	substat, ok := bySlug[store.Slug(roll.Name)]
+	if !ok {
+		errs.add(field+".name", "unknown substat %q", roll.Name)
+		return substat, false
+	}
+	if seen[substat.Name] {
+		errs.add(field+".name", "%s is listed more than once", substat.Name)
+		return substat, false
+	}
+	seen[substat.Name] = true
+
+	if roll.Value < substat.Min || roll.Value > substat.Max {
+		errs.add(field+".value", "%g is outside the %s range %g-%g", roll.Value, substat.Name, substat.Min, substat.Max)
+		return substat, false
+	}
+	return substat, true
+}
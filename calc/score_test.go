@@ -0,0 +1,93 @@
+package calc
+
+import (
+	"testing"
+
+	"api/models"
+	"api/store"
+)
+
+func TestScoreWeights(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+	// Round ranges so percentiles are easy to follow.
+	substats := []models.Substat{
+		{Name: "Crit Rate", Min: 0, Max: 10},
+		{Name: "Crit Dmg", Min: 0, Max: 20},
+		{Name: "ATK%", Min: 0, Max: 10},
+		{Name: "ATK", Min: 0, Max: 100},
+		{Name: "HP", Min: 0, Max: 1000},
+		{Name: "Energy Regen", Min: 0, Max: 10},
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		weights map[string]float64
+		rolls   []StatValue
+		want    float64
+	}{
+		// Ten substats weigh 1 in the default profile, so the best echo
+		// totals 5 × 100. Flat ATK weighs half: (4 × 100 + 0.5 × 100) / 5.
+		{"default, maxed", "", nil, []StatValue{{"Crit Rate", 10}, {"Crit Dmg", 20}, {"ATK%", 10}, {"Energy Regen", 10}, {"ATK", 100}}, 90},
+		// Half rolls of crit: (50 + 50) / (1 + 1 + 0.75 + 0.5 + 0.5).
+		{"crit, half rolls", "crit", nil, []StatValue{{"Crit Rate", 5}, {"Crit Dmg", 10}}, 26.67},
+		// (100 + 0.25 × 50) / (1 + 0.75 + 0.5 + 0.5 + 0.25).
+		{"support", "support", nil, []StatValue{{"Energy Regen", 10}, {"HP", 500}}, 37.5},
+		// Raising ATK to 2 raises the best echo to 2 + 4 × 1: 2 × 100 / 6.
+		{"weight raised", "", map[string]float64{"atk": 2}, []StatValue{{"ATK", 100}}, 33.33},
+		// A zero weight makes a substat worthless without changing the best.
+		{"weight zeroed", "", map[string]float64{"Crit Rate": 0}, []StatValue{{"Crit Rate", 10}}, 0},
+		{"minimum rolls", "crit", nil, []StatValue{{"Crit Rate", 0}, {"Crit Dmg", 0}}, 0},
+		{"no substats", "", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		result, err := Score(m.Stats(), substats, ScoreInput{Cost: 4, MainStat: "CRIT Rate", Substats: tt.rolls, Profile: tt.profile, Weights: tt.weights})
+		if err != nil {
+			t.Errorf("%s: Score() = %v", tt.name, err)
+			continue
+		}
+		if result.Score != tt.want {
+			t.Errorf("%s: Score() = %g, want %g", tt.name, result.Score, tt.want)
+		}
+	}
+}
+
+func TestScoreErrors(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+	tests := []struct {
+		name  string
+		in    ScoreInput
+		field string
+	}{
+		{"profile", ScoreInput{Profile: "dps"}, "profile"},
+		{"unknown weight", ScoreInput{Weights: map[string]float64{"Luck": 1}}, "weights.Luck"},
+		{"negative weight", ScoreInput{Weights: map[string]float64{"ATK": -1}}, "weights.ATK"},
+		{"unknown substat", ScoreInput{Substats: []StatValue{{"Luck", 1}}}, "substats[0].name"},
+		{"repeated substat", ScoreInput{Substats: []StatValue{{"ATK", 30}, {"atk", 40}}}, "substats[1].name"},
+		{"out of range", ScoreInput{Substats: []StatValue{{"Crit Rate", 50}}}, "substats[0].value"},
+		{"too many", ScoreInput{Substats: make([]StatValue, MaxSubstats+1)}, "substats"},
+	}
+	for _, tt := range tests {
+		tt.in.Cost, tt.in.MainStat = 4, "CRIT Rate"
+		_, err := Score(m.Stats(), m.Substats(), tt.in)
+		errs, ok := err.(ValidationErrors)
+		if !ok || !hasField(errs, tt.field) {
+			t.Errorf("%s: Score() = %v, want an error on %s", tt.name, err, tt.field)
+		}
+	}
+}
+
+func hasField(errs ValidationErrors, field string) bool {
+	for _, e := range errs {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package calc
+
+import (
+	"math/rand/v2"
+	"slices"
+
+	"api/models"
+)
+
+// MaxSimulations bounds the batch size of a single simulation request.
+const MaxSimulations = 100000
+
+// SimulateInput describes the echoes to simulate.
+type SimulateInput struct {
+	Cost     int    `json:"cost"`
+	MainStat string `json:"mainStat"`
+	Level    int    `json:"level"`
+	Seed     uint64 `json:"seed"`
+	Count    int    `json:"count"`
+}
+
+// SubstatDistribution summarises how often a substat was rolled across a
+// batch and the spread of its values when it was.
+type SubstatDistribution struct {
+	Name string `json:"name"`
+	// Frequency is the share of echoes that rolled the substat.
+	Frequency float64 `json:"frequency"`
+	// Expected is the mean value per echo, counting echoes without it as 0.
+	Expected    float64            `json:"expected"`
+	Mean        float64            `json:"mean"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+// SimulateResult is the outcome of a simulation. Substats is filled for a
+// single echo, Distribution for a batch.
+type SimulateResult struct {
+	Cost          int                   `json:"cost"`
+	Level         int                   `json:"level"`
+	Seed          uint64                `json:"seed"`
+	Count         int                   `json:"count"`
+	MainStat      StatValue             `json:"mainStat"`
+	SecondaryStat []StatValue           `json:"secondaryStats"`
+	Substats      []StatValue           `json:"substats,omitempty"`
+	Distribution  []SubstatDistribution `json:"distribution,omitempty"`
+}
+
+var percentiles = []struct {
+	name string
+	p    float64
+}{{"p5", 5}, {"p25", 25}, {"p50", 50}, {"p75", 75}, {"p95", 95}}
+
+// Simulate rolls echoes with the given main stat. Main and secondary stats are
+// fixed by cost and level; one substat is drawn per SubstatInterval levels,
+// without repeats, with a value uniformly distributed in its min/max range.
+// The same seed always produces the same rolls.
+func Simulate(stats []models.Stat, substats []models.Substat, in SimulateInput) (SimulateResult, error) {
+	if in.Count == 0 {
+		in.Count = 1
+	}
+	if in.Count < 0 || in.Count > MaxSimulations {
+		return SimulateResult{}, ValidationErrors{{Field: "count", Message: "must be between 1 and 100000"}}
+	}
+
+	main, secondary, err := MainStats(stats, in.Cost, in.MainStat, in.Level)
+	if err != nil {
+		return SimulateResult{}, err
+	}
+
+	result := SimulateResult{
+		Cost:          in.Cost,
+		Level:         in.Level,
+		Seed:          in.Seed,
+		Count:         in.Count,
+		MainStat:      main,
+		SecondaryStat: secondary,
+	}
+
+	rng := rand.New(rand.NewPCG(in.Seed, in.Seed))
+	slots := min(in.Level/SubstatInterval, len(substats))
+
+	if in.Count == 1 {
+		result.Substats = rollSubstats(rng, substats, slots)
+		return result, nil
+	}
+
+	values := make(map[string][]float64, len(substats))
+	for i := 0; i < in.Count; i++ {
+		for _, roll := range rollSubstats(rng, substats, slots) {
+			values[roll.Name] = append(values[roll.Name], roll.Value)
+		}
+	}
+
+	for _, substat := range substats {
+		rolled := values[substat.Name]
+		if len(rolled) == 0 {
+			continue
+		}
+		slices.Sort(rolled)
+
+		var sum float64
+		for _, v := range rolled {
+			sum += v
+		}
+
+		d := SubstatDistribution{
+			Name:        substat.Name,
+			Frequency:   round(float64(len(rolled)) / float64(in.Count)),
+			Expected:    round(sum / float64(in.Count)),
+			Mean:        round(sum / float64(len(rolled))),
+			Percentiles: make(map[string]float64, len(percentiles)),
+		}
+		for _, p := range percentiles {
+			d.Percentiles[p.name] = percentile(rolled, p.p)
+		}
+		result.Distribution = append(result.Distribution, d)
+	}
+	return result, nil
+}
+
+func rollSubstats(rng *rand.Rand, substats []models.Substat, slots int) []StatValue {
+	rolls := make([]StatValue, 0, slots)
+	for _, i := range rng.Perm(len(substats))[:slots] {
+		substat := substats[i]
+		value := substat.Min + rng.Float64()*(substat.Max-substat.Min)
+		rolls = append(rolls, StatValue{Name: substat.Name, Value: round(value)})
+	}
+	return rolls
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(p/100*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
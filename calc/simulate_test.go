@@ -0,0 +1,112 @@
+package calc
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"api/store"
+)
+
+func TestSimulateSeed(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+	stats, substats := m.Stats(), m.Substats()
+
+	tests := []struct {
+		name string
+		in   SimulateInput
+	}{
+		{"single echo", SimulateInput{Cost: 4, MainStat: "CRIT Rate", Level: 25, Seed: 42}},
+		{"half levelled", SimulateInput{Cost: 3, MainStat: "ATK%", Level: 10, Seed: 7}},
+		{"batch", SimulateInput{Cost: 1, MainStat: "HP%", Level: 25, Seed: 1, Count: 500}},
+	}
+	for _, tt := range tests {
+		first, err := Simulate(stats, substats, tt.in)
+		if err != nil {
+			t.Fatalf("%s: Simulate() = %v", tt.name, err)
+		}
+		again, err := Simulate(stats, substats, tt.in)
+		if err != nil || !reflect.DeepEqual(first, again) {
+			t.Errorf("%s: Simulate() with seed %d gave %+v, then %+v", tt.name, tt.in.Seed, first, again)
+		}
+
+		other := tt.in
+		other.Seed++
+		if different, _ := Simulate(stats, substats, other); reflect.DeepEqual(first.Substats, different.Substats) && reflect.DeepEqual(first.Distribution, different.Distribution) {
+			t.Errorf("%s: seeds %d and %d rolled the same", tt.name, tt.in.Seed, other.Seed)
+		}
+
+		slots := tt.in.Level / SubstatInterval
+		if tt.in.Count <= 1 {
+			if len(first.Substats) != slots {
+				t.Errorf("%s: rolled %d substats, want %d", tt.name, len(first.Substats), slots)
+			}
+			seen := make(map[string]bool)
+			for _, roll := range first.Substats {
+				substat, _ := m.Substat(roll.Name)
+				if seen[roll.Name] || roll.Value < substat.Min || roll.Value > substat.Max {
+					t.Errorf("%s: roll %+v is repeated or outside %g-%g", tt.name, roll, substat.Min, substat.Max)
+				}
+				seen[roll.Name] = true
+			}
+			continue
+		}
+
+		// Every echo rolls slots substats, so the frequencies add up to it.
+		var frequency float64
+		for _, d := range first.Distribution {
+			frequency += d.Frequency
+			if d.Percentiles["p5"] > d.Percentiles["p50"] || d.Percentiles["p50"] > d.Percentiles["p95"] {
+				t.Errorf("%s: %s percentiles out of order: %v", tt.name, d.Name, d.Percentiles)
+			}
+		}
+		if math.Abs(frequency-float64(slots)) > 0.1 {
+			t.Errorf("%s: frequencies add up to %g, want %d", tt.name, frequency, slots)
+		}
+	}
+
+	for _, count := range []int{-1, MaxSimulations + 1} {
+		if _, err := Simulate(stats, substats, SimulateInput{Cost: 4, MainStat: "CRIT Rate", Level: 25, Count: count}); err == nil {
+			t.Errorf("Simulate(count %d) succeeded", count)
+		}
+	}
+}
+
+// TestSimulateGolden pins the rolls of one seed, so a change to how echoes are
+// rolled shows up as a changed result rather than passing unnoticed.
+func TestSimulateGolden(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+	result, err := Simulate(m.Stats(), m.Substats(), SimulateInput{Cost: 4, MainStat: "CRIT Rate", Level: 25, Seed: 42})
+	if err != nil {
+		t.Fatalf("Simulate() = %v", err)
+	}
+	want := []StatValue{
+		{Name: "ATK", Value: 68.29},
+		{Name: "HP%", Value: 9.93},
+		{Name: "HP", Value: 267.27},
+		{Name: "Crit Rate", Value: 7.41},
+		{Name: "Basic Attack Dmg Bonus", Value: 9.43},
+	}
+	if !reflect.DeepEqual(result.Substats, want) {
+		t.Errorf("Simulate(seed 42) = %+v, want %+v", result.Substats, want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    float64
+		want float64
+	}{{0, 1}, {5, 1}, {25, 3}, {50, 5}, {75, 8}, {95, 10}, {100, 10}}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%g) = %g, want %g", tt.p, got, tt.want)
+		}
+	}
+}
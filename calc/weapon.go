@@ -0,0 +1,72 @@
+package calc
+
+import (
+	"api/models"
+)
+
+// WeaponStats are a weapon's base ATK and substat at a level.
+type WeaponStats struct {
+	Name      string               `json:"name"`
+	Type      string               `json:"type"`
+	Level     int                  `json:"level"`
+	Ascension int                  `json:"ascension"`
+	MaxLevel  int                  `json:"maxLevel"`
+	ATK       float64              `json:"atk"`
+	Substat   models.WeaponSubstat `json:"substat"`
+}
+
+// weaponPoints returns a weapon's level curve, with its level 1 stats as
+// the first point when the curve does not list them.
+func weaponPoints(weapon models.Weapon) []models.WeaponStatPoint {
+	points := weapon.Stats.Levels
+	for _, point := range points {
+		if point.Level == 1 && point.Ascension == 0 {
+			return points
+		}
+	}
+	if weapon.Stats.Attack == 0 {
+		return points
+	}
+	base := models.WeaponStatPoint{Level: 1, ATK: float64(weapon.Stats.Attack), Substat: weapon.Stats.Substat.Value}
+	return append([]models.WeaponStatPoint{base}, points...)
+}
+
+// WeaponStatsAt interpolates a weapon's base ATK and substat at level. A
+// level at a cap is taken before ascending unless ascended is set. Weapons
+// without stats.levels keep their level 1 stats at every level.
+func WeaponStatsAt(weapon models.Weapon, level int, ascended bool) (WeaponStats, error) {
+	var errs ValidationErrors
+	points := weaponPoints(weapon)
+	if len(points) == 0 {
+		errs.add("name", "no stats are known for %s", weapon.Name)
+		return WeaponStats{}, errs
+	}
+
+	phase, ok := phaseAt(LevelCaps[:], level, ascended, &errs)
+	if !ok {
+		return WeaponStats{}, errs
+	}
+
+	lo, hi, frac, ok := bracket(points, phase, level, func(p models.WeaponStatPoint) (int, int) { return p.Level, p.Ascension })
+	switch {
+	case !ok && len(weapon.Stats.Levels) > 0:
+		errs.add("level", "no stats are known for %s at level %d, ascension %d", weapon.Name, level, phase)
+		return WeaponStats{}, errs
+	case !ok:
+		// Without a level curve only the level 1 stats are known, which
+		// are taken at every level.
+		lo, hi, frac = points[0], points[0], 0
+	}
+
+	substat := weapon.Stats.Substat
+	substat.Value = round(lerp(lo.Substat, hi.Substat, frac))
+	return WeaponStats{
+		Name:      weapon.Name,
+		Type:      weapon.Type,
+		Level:     level,
+		Ascension: phase,
+		MaxLevel:  LevelCaps[phase],
+		ATK:       round(lerp(lo.ATK, hi.ATK, frac)),
+		Substat:   substat,
+	}, nil
+}
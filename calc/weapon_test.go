@@ -0,0 +1,49 @@
+package calc
+
+import (
+	"testing"
+
+	"api/models"
+	"api/store"
+)
+
+func TestWeaponStatsAt(t *testing.T) {
+	var weapon models.Weapon
+	weapon.Name = "Abyss Surges"
+	weapon.Stats.Attack = 40
+	weapon.Stats.Substat = models.WeaponSubstat{Name: "ATK", Value: 8, Unit: "%"}
+
+	stats, err := WeaponStatsAt(weapon, 90, false)
+	if err != nil || stats.ATK != 40 || stats.Substat.Value != 8 {
+		t.Errorf("WeaponStatsAt(90) = %+v, %v, want the level 1 stats without a curve", stats, err)
+	}
+
+	weapon.Stats.Levels = []models.WeaponStatPoint{{Level: 20, ATK: 100, Substat: 12}}
+	if stats, err := WeaponStatsAt(weapon, 10, false); err != nil || stats.ATK != 68.42 {
+		t.Errorf("WeaponStatsAt(10) = %+v, %v, want ATK 9/19 of the way to level 20", stats, err)
+	}
+	if _, err := WeaponStatsAt(weapon, 30, false); err == nil {
+		t.Errorf("WeaponStatsAt(30) succeeded past the end of the curve")
+	}
+}
+
+// TestWeaponStatsAtData interpolates every weapon of the shipped data at
+// every level.
+func TestWeaponStatsAtData(t *testing.T) {
+	m, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+
+	for _, weapon := range m.EachWeapon() {
+		if len(weapon.Stats.Levels) == 0 {
+			t.Errorf("%s: has no stats.levels", weapon.Name)
+			continue
+		}
+		for level := 1; level <= models.MaxCharacterLevel; level++ {
+			if _, err := WeaponStatsAt(weapon, level, false); err != nil {
+				t.Errorf("WeaponStatsAt(%s, %d) = %v", weapon.Name, level, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"api/store"
+)
+
+func ReloadHandler(reloader *store.Reloader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := reloader.Reload(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Reload failed, still serving previous data",
+				"error":   err.Error(),
+				"reload":  reloader.Status(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "reload": reloader.Status()})
+	}
+}
+
+func ReloadStatusHandler(reloader *store.Reloader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"reload": reloader.Status()})
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"api/apikeys"
+)
+
+// keySummary is an API key as shown to admins, without its hash.
+type keySummary struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Tier    string         `json:"tier"`
+	Created time.Time      `json:"created"`
+	Revoked *time.Time     `json:"revoked,omitempty"`
+	Today   int            `json:"today"`
+	Usage   map[string]int `json:"usage,omitempty"`
+}
+
+func summarizeKey(keys *apikeys.Keyring, key apikeys.Key, now time.Time) keySummary {
+	return keySummary{
+		ID:      key.ID,
+		Name:    key.Name,
+		Tier:    key.Tier,
+		Created: key.Created,
+		Revoked: key.Revoked,
+		Today:   keys.Used(key.ID, now),
+	}
+}
+
+func ListKeysHandler(keys *apikeys.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		list := []keySummary{}
+		for _, key := range keys.Keys() {
+			list = append(list, summarizeKey(keys, key, now))
+		}
+		c.JSON(http.StatusOK, gin.H{"keys": list, "tiers": keys.Tiers()})
+	}
+}
+
+func GetKeyHandler(keys *apikeys.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := keys.Key(c.Param("id"))
+		if !ok {
+			NotFoundHandler(c, "API key not found")
+			return
+		}
+		summary := summarizeKey(keys, key, time.Now())
+		summary.Usage = keys.Usage(key.ID)
+		c.JSON(http.StatusOK, summary)
+	}
+}
+
+func CreateKeyHandler(keys *apikeys.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var in struct {
+			Name string `json:"name"`
+			Tier string `json:"tier"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			BadRequestHandler(c, "Invalid JSON body")
+			return
+		}
+
+		now := time.Now()
+		token, key, err := keys.Create(in.Name, in.Tier, now)
+		var input apikeys.InputError
+		if errors.As(err, &input) {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"status": "ok", "key": summarizeKey(keys, key, now), "token": token})
+	}
+}
+
+func RevokeKeyHandler(keys *apikeys.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		key, err := keys.Revoke(c.Param("id"), now)
+		if errors.Is(err, apikeys.ErrUnknownKey) {
+			NotFoundHandler(c, "API key not found")
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "key": summarizeKey(keys, key, now)})
+	}
+}
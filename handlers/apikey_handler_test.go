@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"api/apikeys"
+)
+
+func TestCreateKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		name, dir, body string
+		want            int
+	}{
+		{"created", "", `{"name": "bot", "tier": "free"}`, http.StatusCreated},
+		{"unknown tier", "", `{"name": "bot", "tier": "gold"}`, http.StatusBadRequest},
+		{"no name", "", `{"tier": "free"}`, http.StatusBadRequest},
+		{"bad JSON", "", `{`, http.StatusBadRequest},
+		// The keys file cannot be written in a directory that is not there.
+		{"save failure", "missing", `{"name": "bot", "tier": "free"}`, http.StatusInternalServerError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := apikeys.Open(filepath.Join(t.TempDir(), tc.dir, "keys.json"))
+			if err != nil {
+				t.Fatalf("Open() = %v", err)
+			}
+			r := gin.New()
+			r.POST("/admin/keys", CreateKeyHandler(keys))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(tc.body)))
+			if w.Code != tc.want {
+				t.Errorf("POST %s = %d %s, want %d", tc.body, w.Code, w.Body, tc.want)
+			}
+		})
+	}
+}
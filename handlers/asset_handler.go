@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"api/assets"
+)
+
+// serveAsset writes the image at path, resized and converted as the size, w,
+// h and format query parameters ask, with its content type and caching
+// headers, answering 304 when the client already has it.
+func serveAsset(c *gin.Context, images *assets.Images, path string, notFound string) {
+	transform, err := assets.ParseTransform(c.Request.URL.Query(), images.MaxDimension)
+	if err != nil {
+		BadRequestHandler(c, err.Error())
+		return
+	}
+
+	asset, err := images.Get(c.Request.Context(), path, transform)
+	if errors.Is(err, assets.ErrNotFound) {
+		NotFoundHandler(c, notFound)
+		return
+	}
+	if errors.Is(err, assets.ErrUnsupported) {
+		log.Printf("Error transforming asset %s: %v", path, err)
+		BadRequestHandler(c, "Image cannot be resized or converted")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching asset %s: %v", path, err)
+		c.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": "Failed to fetch asset"})
+		return
+	}
+
+	if asset.CacheControl != "" {
+		c.Header("Cache-Control", asset.CacheControl)
+	}
+	if asset.ETag != "" {
+		c.Header("ETag", asset.ETag)
+	}
+	if asset.LastModified != "" {
+		c.Header("Last-Modified", asset.LastModified)
+	}
+	if notModified(c, asset) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Length", strconv.Itoa(len(asset.Body)))
+	c.Data(http.StatusOK, asset.ContentType, asset.Body)
+}
+
+// notModified reports whether the request's If-None-Match or, lacking it,
+// If-Modified-Since shows the client already has asset.
+func notModified(c *gin.Context, asset *assets.Asset) bool {
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		return asset.ETag != "" && etagMatches(match, asset.ETag)
+	}
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil || asset.LastModified == "" {
+		return false
+	}
+	modified, err := http.ParseTime(asset.LastModified)
+	return err == nil && !modified.After(since)
+}
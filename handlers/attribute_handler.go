@@ -2,59 +2,50 @@ package handlers
 
 import (
 	"strings"
-	"fmt"
-	"io"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"api/models" 
+	"api/assets"
+	"api/models"
+	"api/store"
 )
 
-func ListAttributesHandler(attributes []models.Attribute) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var attributeNames []string
-		for _, attribute := range attributes {
-			attributeNames = append(attributeNames, attribute.Name)
-		}
-		c.JSON(http.StatusOK, gin.H{"attributes": attributeNames})
-	}
+var attributeFilters = map[string]filterField[models.Attribute]{
+	"name": {text: func(a models.Attribute) []string { return []string{a.Name} }},
 }
 
-func GetAttributeHandler(attributes []models.Attribute) gin.HandlerFunc {
+func ListAttributesHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		name := strings.ToLower(c.Param("name"))
+		filters, err := parseFilters(c.Request.URL.Query(), attributeFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
 
-		for _, attribute := range attributes {
-			if strings.ToLower(attribute.Name) == name {
-				c.JSON(http.StatusOK, attribute)
-				return
+		var matched []models.Attribute
+		for _, attribute := range s.Attributes() {
+			if matchFilters(attribute, filters) {
+				matched = append(matched, attribute)
 			}
 		}
-
-		NotFoundHandler(c, "Attribute not found")
+		writeList(c, "attributes", matched, func(attribute models.Attribute) string { return attribute.Name })
 	}
 }
 
-func AttributeIconHandler(c *gin.Context) {
-	name := strings.ToLower(c.Param("name"))
-	remoteURL := fmt.Sprintf("%sattributes/icon/%s.webp", cdnURL, name)
-	
-	resp, err := http.Get(remoteURL)
-	if err != nil {
-		NotFoundHandler(c, "Failed to fetch icon")
-		return
-	}
-	defer resp.Body.Close()
+func GetAttributeHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attribute, ok := s.Attribute(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindAttributes, c.Param("name"), "Attribute not found")
+			return
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		NotFoundHandler(c, "Failed to fetch icon")
-		return
+		writeItem(c, attribute)
 	}
+}
 
-	c.Header("Content-Type", "image/webp")
-	_, err = io.Copy(c.Writer, resp.Body)
-	if err != nil {
-		NotFoundHandler(c, "Failed to serve icon")
-		return
+func AttributeIconHandler(images *assets.Images) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := strings.ToLower(c.Param("name"))
+		serveAsset(c, images, assets.AttributeIcon(name), "Failed to fetch icon")
 	}
 }
\ No newline at end of file
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"api/calc"
+	"api/store"
+)
+
+func EvaluateBuildHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var in calc.BuildInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			BadRequestHandler(c, "Invalid JSON body")
+			return
+		}
+
+		result, err := calc.EvaluateBuild(s, in)
+		if err != nil {
+			InvalidInputHandler(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func OptimizeEchoesHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var in calc.OptimizeInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			BadRequestHandler(c, "Invalid JSON body")
+			return
+		}
+
+		result, err := calc.Optimize(s, in)
+		if err != nil {
+			InvalidInputHandler(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
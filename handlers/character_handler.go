@@ -2,143 +2,123 @@ package handlers
 
 import (
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"api/assets"
 	"api/models"
+	"api/store"
 )
 
-func ListCharactersHandler(characters []models.Character) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var characterNames []string
-		for _, character := range characters {
-			characterNames = append(characterNames, character.Name)
-		}
-		c.JSON(http.StatusOK, gin.H{"characters": characterNames})
-	}
+var characterFilters = map[string]filterField[models.Character]{
+	"name":       {text: func(c models.Character) []string { return []string{c.Name} }},
+	"attribute":  {text: func(c models.Character) []string { return []string{c.Attribute} }},
+	"weapon":     {text: func(c models.Character) []string { return []string{c.Weapon} }},
+	"class":      {text: func(c models.Character) []string { return []string{c.Class} }},
+	"birthplace": {text: func(c models.Character) []string { return []string{c.Birthplace} }},
+	"rarity":     {number: func(c models.Character) float64 { return float64(c.Rarity) }},
 }
 
-
-func GetCharacterHandler(characters []models.Character) gin.HandlerFunc {
+func ListCharactersHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		name := strings.ToLower(c.Param("name"))
-		name = strings.ReplaceAll(name, " ", "%20")
+		filters, err := parseFilters(c.Request.URL.Query(), characterFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
 
-		for _, character := range characters {
-			if strings.ToLower(strings.ReplaceAll(character.Name, " ", "%20")) == name {
-				c.JSON(http.StatusOK, character)
-				return
+		var matched []models.Character
+		for character := range s.EachCharacter() {
+			if matchFilters(character, filters) {
+				matched = append(matched, character)
 			}
 		}
+		if err := sortItems(matched, c.Query("sort"), characterFilters); err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
 
-		NotFoundHandler(c, "Character not found")
-	}
-}
-
-func CharacterEmojisHandler(c *gin.Context) {
-	name := c.Param("name")
-	name = strings.ReplaceAll(name, " ", "%20")
-	emojiList, err := loadEmojis(name)
-	if err != nil {
-		NotFoundHandler(c, "Failed to load emojis")
-		return
+		writeList(c, "characters", matched, func(character models.Character) string { return character.Name })
 	}
-	c.JSON(http.StatusOK, emojiList)
 }
 
-func CharacterEmojiHandler(c *gin.Context) {
-	name := c.Param("name")
-	name = strings.ReplaceAll(name, " ", "%20")
-	index := c.Param("index")
-
-	emojiURL := fmt.Sprintf("%semojis/%s/%s.png", cdnURL, name, index)
-	resp, err := http.Get(emojiURL)
-	if err != nil {
-		NotFoundHandler(c, "Failed to fetch emoji")
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		NotFoundHandler(c, "Failed to fetch emoji")
-		return
-	}
 
-	c.Header("Content-Type", "image/png")
+func GetCharacterHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		character, ok := s.Character(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindCharacters, c.Param("name"), "Character not found")
+			return
+		}
 
-	_, err = io.Copy(c.Writer, resp.Body)
-	if err != nil {
-		NotFoundHandler(c, "Failed to serve emoji")
-		return
+		writeItem(c, character)
 	}
 }
 
-func CharacterImageHandler(c *gin.Context) {
-	name := strings.ToLower(c.Param("name"))
-	name = strings.ReplaceAll(name, " ", "_")
-	imageType := c.Param("imagetype")
-
-	validTypes := map[string]bool{
-		"portrait": true,
-		"icon":     true,
-		"circle":   true,
-		"card":     true,
-	}
-
-	if !validTypes[imageType] {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid image type", "docs": docsURL})
-		return
+func CharacterEmojisHandler(images *assets.Images) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		emojiList, err := loadEmojis(c, images.Source, name)
+		if err != nil {
+			NotFoundHandler(c, "Failed to load emojis")
+			return
+		}
+		c.JSON(http.StatusOK, emojiList)
 	}
+}
 
-	remoteURL := fmt.Sprintf("%scharacters/%ss/%s.png", cdnURL, imageType, name)
+func CharacterEmojiHandler(images *assets.Images) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		index, err := strconv.Atoi(c.Param("index"))
+		if err != nil || index < 0 {
+			NotFoundHandler(c, "Failed to fetch emoji")
+			return
+		}
 
-	resp, err := http.Get(remoteURL)
-	if err != nil {
-		NotFoundHandler(c, "Character image not found")
-		return
+		serveAsset(c, images, assets.CharacterEmoji(c.Param("name"), index), "Failed to fetch emoji")
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		NotFoundHandler(c, "Failed to fetch file")
-		return
-	}
+func CharacterImageHandler(images *assets.Images) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := strings.ToLower(c.Param("name"))
+		name = strings.ReplaceAll(name, " ", "_")
+		imageType := c.Param("imagetype")
+
+		validTypes := map[string]bool{
+			"portrait": true,
+			"icon":     true,
+			"circle":   true,
+			"card":     true,
+		}
 
-	c.Header("Content-Type", "image/png")
+		if !validTypes[imageType] {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid image type", "docs": docsURL})
+			return
+		}
 
-	_, err = io.Copy(c.Writer, resp.Body)
-	if err != nil {
-		NotFoundHandler(c, "Failed to serve file")
-		return
+		serveAsset(c, images, assets.CharacterImage(imageType, name), "Character image not found")
 	}
 }
 
-func loadEmojis(charName string) (*models.Emojis, error) {
+// loadEmojis lists the links to a character's emojis, which are numbered from
+// 0 up. Sources that cannot be linked to directly are linked through the API.
+func loadEmojis(c *gin.Context, source assets.Source, charName string) (*models.Emojis, error) {
 	emojiList := &models.Emojis{}
 	for i := 0; ; i++ {
-		emojiPath, err := emojisPerCharacter(charName, fmt.Sprintf("%d", i))
-		if err != nil {
+		emojiPath := assets.CharacterEmoji(charName, i)
+		if _, err := source.Get(c.Request.Context(), emojiPath); err != nil {
 			break
 		}
-		emojiList.Emojis = append(emojiList.Emojis, emojiPath)
+
+		if linker, ok := source.(assets.Linker); ok {
+			emojiList.Emojis = append(emojiList.Emojis, linker.URL(emojiPath))
+		} else {
+			emojiList.Emojis = append(emojiList.Emojis, fmt.Sprintf("%s/characters/%s/emojis/%d", baseURL(c), url.PathEscape(charName), i))
+		}
 	}
 	return emojiList, nil
 }
-
-func emojisPerCharacter(name string, index string) (string, error) {
-	name = strings.ReplaceAll(name, " ", "_")
-	emojiURL := fmt.Sprintf("%scharacters/emojis/%s/%s.png", cdnURL, name, index)
-	resp, err := http.Get(emojiURL)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Failed to fetch emoji")
-	}
-
-	return emojiURL, nil
-}
\ No newline at end of file
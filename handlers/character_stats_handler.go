@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"api/calc"
+	"api/store"
+)
+
+func CharacterStatsHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		character, ok := s.Character(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindCharacters, c.Param("name"), "Character not found")
+			return
+		}
+		if len(character.Stats) == 0 {
+			NotFoundHandler(c, "No stats known for this character")
+			return
+		}
+
+		if c.Query("level") == "" {
+			c.JSON(http.StatusOK, gin.H{"name": character.Name, "stats": character.Stats, "ascension": character.Ascension})
+			return
+		}
+
+		level, err := queryInt(c, "level", 0)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+		stats, err := calc.StatsAt(character, level, isTrue(c.Query("ascended")))
+		if err != nil {
+			InvalidInputHandler(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+func CharacterSkillsHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		character, ok := s.Character(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindCharacters, c.Param("name"), "Character not found")
+			return
+		}
+		if len(character.Skills) == 0 {
+			NotFoundHandler(c, "No skills known for this character")
+			return
+		}
+
+		skills := character.Skills
+		if c.Query("level") != "" {
+			level, err := queryInt(c, "level", 0)
+			if err != nil {
+				BadRequestHandler(c, err.Error())
+				return
+			}
+			skills, err = calc.SkillsAt(character, level)
+			if err != nil {
+				InvalidInputHandler(c, err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"name": character.Name, "skills": skills})
+	}
+}
+
+func CharacterChainsHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		character, ok := s.Character(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindCharacters, c.Param("name"), "Character not found")
+			return
+		}
+		if len(character.Chains) == 0 {
+			NotFoundHandler(c, "No resonance chain known for this character")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"name": character.Name, "chains": character.Chains})
+	}
+}
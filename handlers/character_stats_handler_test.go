@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"api/calc"
+	"api/models"
+	"api/store"
+)
+
+func characterRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	s, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON() = %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/characters/:name/skills", CharacterSkillsHandler(s))
+	r.GET("/characters/:name/chains", CharacterChainsHandler(s))
+	r.POST("/calc/damage", DamageHandler(s))
+	return r
+}
+
+func serve(t *testing.T, r *gin.Engine, method, target, body string, out interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s %s = %d, want 200: %s", method, target, w.Code, w.Body)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+}
+
+func TestCharacterDataIsComplete(t *testing.T) {
+	s, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON() = %v", err)
+	}
+	for _, character := range s.Characters() {
+		if len(character.Chains) != models.MaxChainSequence {
+			t.Errorf("%s has %d chain nodes, want %d", character.Name, len(character.Chains), models.MaxChainSequence)
+		}
+		multipliers := 0
+		for _, skill := range character.Skills {
+			for _, multiplier := range skill.Multipliers {
+				multipliers++
+				if len(multiplier.Values) != models.MaxSkillLevel {
+					t.Errorf("%s %s %s has %d values, want %d", character.Name, skill.Type, multiplier.Name, len(multiplier.Values), models.MaxSkillLevel)
+				}
+			}
+		}
+		if multipliers == 0 {
+			t.Errorf("%s has no skill multipliers", character.Name)
+		}
+	}
+}
+
+func TestCharacterChainsHandler(t *testing.T) {
+	var body struct {
+		Name   string                  `json:"name"`
+		Chains []models.ResonanceChain `json:"chains"`
+	}
+	serve(t, characterRouter(t), http.MethodGet, "/characters/jiyan/chains", "", &body)
+	if len(body.Chains) != models.MaxChainSequence {
+		t.Fatalf("got %d chain nodes, want %d", len(body.Chains), models.MaxChainSequence)
+	}
+	for i, chain := range body.Chains {
+		if chain.Sequence != i+1 || chain.Name == "" {
+			t.Errorf("chains[%d] = %+v", i, chain)
+		}
+	}
+}
+
+func TestCharacterSkillsHandlerLevel(t *testing.T) {
+	var body struct {
+		Skills []models.CharacterSkill `json:"skills"`
+	}
+	serve(t, characterRouter(t), http.MethodGet, "/characters/jiyan/skills?level=5", "", &body)
+
+	filled := 0
+	for _, skill := range body.Skills {
+		for _, multiplier := range skill.Multipliers {
+			if multiplier.Level != 5 || multiplier.Value == nil {
+				t.Errorf("%s %s: level %d, value %v", skill.Type, multiplier.Name, multiplier.Level, multiplier.Value)
+				continue
+			}
+			if *multiplier.Value != multiplier.Values[4] {
+				t.Errorf("%s %s = %g, want %g", skill.Type, multiplier.Name, *multiplier.Value, multiplier.Values[4])
+			}
+			filled++
+		}
+	}
+	if filled == 0 {
+		t.Fatal("no multiplier values filled in")
+	}
+}
+
+func TestDamageHandlerSkillName(t *testing.T) {
+	r := characterRouter(t)
+	var skills struct {
+		Skills []models.CharacterSkill `json:"skills"`
+	}
+	serve(t, r, http.MethodGet, "/characters/jiyan/skills", "", &skills)
+	var want float64
+	for _, skill := range skills.Skills {
+		if skill.Type == "Resonance Skill" {
+			want = skill.Multipliers[0].Values[9]
+		}
+	}
+
+	var result calc.DamageResult
+	serve(t, r, http.MethodPost, "/calc/damage", `{
+		"character": "jiyan",
+		"level": 90,
+		"weapon": {"type": "broadblade", "name": "verdant summit", "refinement": 1, "level": 90},
+		"skill": {"type": "resonance-skill", "name": "skill dmg", "level": 10},
+		"enemy": {"level": 90}
+	}`, &result)
+	for _, step := range result.Steps {
+		if step.Name == "Base damage" {
+			if !strings.HasSuffix(step.Formula, fmt.Sprintf("× %g%%", want)) {
+				t.Errorf("base damage formula %q does not use the level 10 multiplier %g", step.Formula, want)
+			}
+			return
+		}
+	}
+	t.Fatalf("no base damage step in %+v", result.Steps)
+}
@@ -1,22 +1,77 @@
 package handlers
 
 import (
-	"github.com/gin-gonic/gin"
 	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
 	"api/models"
+	"api/store"
 )
 
-func CodesHandler(codes []models.Code) gin.HandlerFunc {
+var codeFilters = map[string]filterField[models.Code]{
+	"name": {text: func(c models.Code) []string { return []string{c.Name} }},
+	"item": {text: func(c models.Code) []string {
+		items := make([]string, len(c.Rewards))
+		for i, reward := range c.Rewards {
+			items[i] = reward.Item
+		}
+		return items
+	}},
+}
+
+// CodesHandler lists the redemption codes. ?status keeps the active or the
+// expired ones and ?region the ones redeemable in a region, codes without a
+// region being redeemable everywhere.
+func CodesHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var codesWithRewards []gin.H 
+		status := c.Query("status")
+		if status != "" && status != models.CodeActive && status != models.CodeExpired {
+			BadRequestHandler(c, "Invalid status, expected active or expired")
+			return
+		}
+		region := store.Slug(c.Query("region"))
 
-		for _, code := range codes {
-			codesWithRewards = append(codesWithRewards, gin.H{
-				"name":   code.Name,
-				"reward": code.Reward, 
-			})
+		filters, err := parseFilters(c.Request.URL.Query(), codeFilters, "status", "region")
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"codes": codesWithRewards})
+		now := time.Now()
+		codes := []models.Code{}
+		for code := range s.EachCode() {
+			code.Status = code.StatusAt(now)
+			if status != "" && code.Status != status {
+				continue
+			}
+			if region != "" && code.Region != "" && store.Slug(code.Region) != region {
+				continue
+			}
+			if matchFilters(code, filters) {
+				codes = append(codes, code)
+			}
+		}
+
+		response := gin.H{"total": len(codes)}
+		codes, err = paginate(c, codes, response)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+		response["codes"] = codes
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func GetCodeHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code, ok := s.Code(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindCodes, c.Param("name"), "Code not found")
+			return
+		}
+		code.Status = code.StatusAt(time.Now())
+		writeItem(c, code)
 	}
-}
\ No newline at end of file
+}
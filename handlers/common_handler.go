@@ -1,30 +1,54 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"api/models"
+	"api/calc"
+	"api/store"
 )
 
-var (
-	docsURL = "https://github.com/whosneksio/resonance.rest/blob/main/README.md"
-	cdnURL  = "http://cdn.resonance.rest/"
-)
+var docsURL = "https://github.com/whosneksio/resonance.rest/blob/main/README.md"
 
-func HomeHandler(characters []models.Character, attributes []models.Attribute, weapons map[string][]models.Weapon, echoes []models.Echo) gin.HandlerFunc {
+func HomeHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"version": "2.0",
 			"statistics": gin.H{
-				"attributes": len(attributes),
-				"characters": len(characters),
-				"weapons":    len(weapons),
-				"echoes":     len(echoes),
+				"attributes": len(s.Attributes()),
+				"characters": len(s.Characters()),
+				"weapons":    len(s.WeaponTypes()),
+				"echoes":     len(s.Echoes()),
 			},
 		})
 	}
 }
 
+// InvalidInputHandler answers a request whose body failed validation, listing
+// every invalid field when the error carries them.
+func InvalidInputHandler(c *gin.Context, err error) {
+    var fieldErrors calc.ValidationErrors
+    if errors.As(err, &fieldErrors) {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request", "errors": fieldErrors, "docs": docsURL})
+        return
+    }
+    BadRequestHandler(c, err.Error())
+}
+
+func BadRequestHandler(c *gin.Context, message string) {
+    c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": message, "docs": docsURL})
+}
+
+// SuggestNotFoundHandler answers a failed lookup of name with the closest
+// names of kind.
+func SuggestNotFoundHandler(c *gin.Context, s store.Store, kind store.Kind, name string, message string) {
+    body := gin.H{"status": "error", "message": message}
+    if suggestions := s.Suggest(kind, name); len(suggestions) > 0 {
+        body["suggestions"] = suggestions
+    }
+    c.JSON(http.StatusNotFound, body)
+}
+
 func NotFoundHandler(c *gin.Context, message ...string) {
     msg := "Not found"
     if len(message) > 0 {
@@ -1,31 +1,67 @@
 package handlers
 
 import (
+	"strconv"
+
 	"github.com/gin-gonic/gin"
-	"net/http"
-	"strings"
+	"api/calc"
 	"api/models"
+	"api/store"
 )
 
-func ListEchoesHandler(echoes []models.Echo) gin.HandlerFunc {
+var echoFilters = map[string]filterField[models.Echo]{
+	"name":   {text: func(e models.Echo) []string { return []string{e.Name} }},
+	"cost":   {number: func(e models.Echo) float64 { return float64(e.Cost) }},
+	"sonata": {text: func(e models.Echo) []string { return e.SonataEffects }},
+}
+
+func ListEchoesHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var echoNames []string
-		for _, echo := range echoes {
-			echoNames = append(echoNames, echo.Name)
+		filters, err := parseFilters(c.Request.URL.Query(), echoFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		var matched []models.Echo
+		for echo := range s.EachEcho() {
+			if matchFilters(echo, filters) {
+				matched = append(matched, echo)
+			}
+		}
+		if err := sortItems(matched, c.Query("sort"), echoFilters); err != nil {
+			BadRequestHandler(c, err.Error())
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{"echoes": echoNames})
+
+		writeList(c, "echoes", matched, func(echo models.Echo) string { return echo.Name })
 	}
 }
 
-func GetEchoHandler(echoes []models.Echo) gin.HandlerFunc {
+func GetEchoHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		name := strings.ReplaceAll(strings.ToLower(c.Param("name")), "_", " ")
-		for _, echo := range echoes {
-			if strings.ToLower(echo.Name) == name {
-				c.JSON(http.StatusOK, echo)
+		echo, ok := s.Echo(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindEchoes, c.Param("name"), "Echo not found")
+			return
+		}
+
+		if value := c.Query("rank"); value != "" {
+			rank, err := strconv.Atoi(value)
+			if err != nil {
+				BadRequestHandler(c, "Invalid rank")
 				return
 			}
+			rendered, err := calc.RenderEcho(echo, rank)
+			if err != nil {
+				InvalidInputHandler(c, err)
+				return
+			}
+			echo.Rank = rank
+			echo.Rendered = rendered
 		}
-		NotFoundHandler(c, "Echo not found")
+
+		echo.Ranks = calc.RenderEchoRanks(echo)
+		writeItem(c, echo)
 	}
 }
\ No newline at end of file
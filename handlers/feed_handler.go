@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"api/models"
+	"api/store"
+)
+
+// feedEpoch dates the feeds' tag URIs and stands in for the last update of a
+// feed without entries, which Atom requires.
+var feedEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// feedEntry is a code as it appears in the feeds. Codes without an added date
+// have no stable publication time and are left out; the validator reports
+// them.
+type feedEntry struct {
+	code      models.Code
+	id        string
+	link      string
+	published time.Time
+	updated   time.Time
+	summary   string
+}
+
+// codeFeed collects the dated codes, newest first, along with the time the
+// feed last changed.
+func codeFeed(c *gin.Context, s store.Store, authority string) ([]feedEntry, time.Time) {
+	base := baseURL(c)
+	now := time.Now()
+
+	var entries []feedEntry
+	var modified time.Time
+	for code := range s.EachCode() {
+		if code.Added == nil {
+			continue
+		}
+		code.Status = code.StatusAt(now)
+
+		entry := feedEntry{
+			code:      code,
+			id:        fmt.Sprintf("tag:%s,%d:codes/%s", authority, code.Added.Year(), code.Name),
+			link:      base + "/codes/" + code.Name,
+			published: code.Added.Time,
+			updated:   code.Added.Time,
+			summary:   codeSummary(code),
+		}
+		if code.Status == models.CodeExpired {
+			entry.updated = code.Expires.End()
+		}
+		if entry.updated.After(modified) {
+			modified = entry.updated
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortStableFunc(entries, func(a, b feedEntry) int {
+		return cmp.Or(b.published.Compare(a.published), cmp.Compare(a.code.Name, b.code.Name))
+	})
+	return entries, modified
+}
+
+func codeSummary(code models.Code) string {
+	summary := "Rewards: " + code.Rewards.String() + "."
+	if code.Region != "" {
+		summary += " Region: " + code.Region + "."
+	}
+	if code.Expires != nil {
+		if code.Status == models.CodeExpired {
+			summary += " Expired on " + code.Expires.End().UTC().Format(time.RFC1123) + "."
+		} else {
+			summary += " Expires on " + code.Expires.End().UTC().Format(time.RFC1123) + "."
+		}
+	}
+	return summary
+}
+
+// baseURL returns the scheme and host the request was made to, honouring a
+// proxy's X-Forwarded-Proto.
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Link      atomLink   `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Category  []atomTerm `xml:"category"`
+}
+
+type atomTerm struct {
+	Term string `xml:"term,attr"`
+}
+
+// CodesAtomHandler serves the codes as an Atom feed. authority is the host
+// name the entries' tag URIs are minted under; it is fixed rather than taken
+// from the request so the IDs stay the same however the feed is reached.
+func CodesAtomHandler(s store.Store, authority string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, modified := codeFeed(c, s.Snapshot(), authority)
+		base := baseURL(c)
+
+		updated := modified
+		if updated.IsZero() {
+			updated = feedEpoch
+		}
+		feed := atomFeed{
+			ID:      fmt.Sprintf("tag:%s,%d:codes", authority, feedEpoch.Year()),
+			Title:   "Wuthering Waves redemption codes",
+			Updated: updated.UTC().Format(time.RFC3339),
+			Links: []atomLink{
+				{Href: base + "/codes/feed.xml", Rel: "self", Type: "application/atom+xml"},
+				{Href: base + "/codes", Rel: "alternate", Type: "application/json"},
+			},
+		}
+		for _, entry := range entries {
+			atom := atomEntry{
+				ID:        entry.id,
+				Title:     entry.code.Name,
+				Published: entry.published.UTC().Format(time.RFC3339),
+				Updated:   entry.updated.UTC().Format(time.RFC3339),
+				Link:      atomLink{Href: entry.link, Rel: "alternate", Type: "application/json"},
+				Summary:   entry.summary,
+				Category:  []atomTerm{{Term: entry.code.Status}},
+			}
+			if entry.code.Region != "" {
+				atom.Category = append(atom.Category, atomTerm{Term: entry.code.Region})
+			}
+			feed.Entries = append(feed.Entries, atom)
+		}
+
+		body, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		writeConditional(c, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), body...), modified)
+	}
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string      `json:"id"`
+	URL           string      `json:"url"`
+	Title         string      `json:"title"`
+	ContentText   string      `json:"content_text"`
+	DatePublished string      `json:"date_published"`
+	DateModified  string      `json:"date_modified"`
+	Tags          []string    `json:"tags"`
+	Code          models.Code `json:"_code"`
+}
+
+// CodesJSONFeedHandler serves the codes as a JSON Feed, with item IDs minted
+// as by CodesAtomHandler.
+func CodesJSONFeedHandler(s store.Store, authority string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, modified := codeFeed(c, s.Snapshot(), authority)
+		base := baseURL(c)
+
+		feed := jsonFeed{
+			Version:     "https://jsonfeed.org/version/1.1",
+			Title:       "Wuthering Waves redemption codes",
+			HomePageURL: base + "/codes",
+			FeedURL:     base + "/codes/feed.json",
+			Items:       []jsonFeedItem{},
+		}
+		for _, entry := range entries {
+			item := jsonFeedItem{
+				ID:            entry.id,
+				URL:           entry.link,
+				Title:         entry.code.Name,
+				ContentText:   entry.summary,
+				DatePublished: entry.published.UTC().Format(time.RFC3339),
+				DateModified:  entry.updated.UTC().Format(time.RFC3339),
+				Tags:          []string{entry.code.Status},
+				Code:          entry.code,
+			}
+			if entry.code.Region != "" {
+				item.Tags = append(item.Tags, entry.code.Region)
+			}
+			feed.Items = append(feed.Items, item)
+		}
+
+		body, err := json.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		writeConditional(c, "application/feed+json; charset=utf-8", body, modified)
+	}
+}
+
+// writeConditional writes body with an ETag and, when known, a Last-Modified
+// header, and answers 304 Not Modified when the request's If-None-Match or,
+// lacking it, If-Modified-Since shows the client already has it.
+func writeConditional(c *gin.Context, contentType string, body []byte, modified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if etagMatches(match, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	} else if since := c.GetHeader("If-Modified-Since"); since != "" && !modified.IsZero() {
+		if t, err := http.ParseTime(since); err == nil && !modified.Truncate(time.Second).After(t) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag.
+func etagMatches(match, etag string) bool {
+	for _, tag := range strings.Split(match, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == strings.TrimPrefix(etag, "W/") || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"api/models"
+	"api/store"
+)
+
+func serveFeed(t *testing.T, codes []models.Code, host string) atomFeed {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/codes/feed.xml", CodesAtomHandler(store.NewMemory(store.Data{Codes: codes}), "api.resonance.rest"))
+
+	req := httptest.NewRequest(http.MethodGet, "/codes/feed.xml", nil)
+	req.Host = host
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /codes/feed.xml = %d", w.Code)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	return feed
+}
+
+func TestCodesAtomIDs(t *testing.T) {
+	added := &models.Date{Time: time.Date(2024, time.May, 22, 0, 0, 0, 0, time.UTC), DateOnly: true}
+	codes := []models.Code{{Name: "WUTHERINGGIFT", Added: added}}
+
+	feed := serveFeed(t, codes, "evil.example:8080")
+	if feed.ID != "tag:api.resonance.rest,2024:codes" {
+		t.Errorf("feed ID = %q, want it under the configured authority", feed.ID)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].ID != "tag:api.resonance.rest,2024:codes/WUTHERINGGIFT" {
+		t.Errorf("entries = %+v, want one under the configured authority", feed.Entries)
+	}
+	if !strings.HasPrefix(feed.Entries[0].Link.Href, "http://evil.example:8080/") {
+		t.Errorf("entry link = %q, want it on the requested host", feed.Entries[0].Link.Href)
+	}
+}
+
+func TestCodesAtomEmpty(t *testing.T) {
+	feed := serveFeed(t, nil, "localhost")
+	if feed.Updated != feedEpoch.Format(time.RFC3339) {
+		t.Errorf("empty feed updated = %q, want %s", feed.Updated, feedEpoch.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"api/store"
+)
+
+// filterField describes how a query parameter reads a value from T. Text
+// fields match when any of their values equals any of the requested ones;
+// number fields additionally support comparisons.
+type filterField[T any] struct {
+	text   func(T) []string
+	number func(T) float64
+}
+
+type filter[T any] struct {
+	field   filterField[T]
+	op      string
+	values  []string
+	numbers []float64
+}
+
+// listOptions are the query parameters every list accepts besides filters.
+var listOptions = []string{"expand", "full", "fields", "sort", "limit", "offset"}
+
+// parseFilters reads the filters for fields out of query. Any other parameter
+// must be one of listOptions or options, so a misspelt filter is an error
+// rather than a silently unfiltered list.
+//
+// Values may be repeated (?rarity=4&rarity=5) or comma separated (?rarity=4,5).
+// Number fields accept the operators =, !=, >=, <=, > and <; because a query
+// string splits on the first '=', "rarity>=4" arrives as the key "rarity>"
+// with the value "4" and "rarity>4" as the key "rarity>4" with no value.
+func parseFilters[T any](query url.Values, fields map[string]filterField[T], options ...string) ([]filter[T], error) {
+	var filters []filter[T]
+	for key, values := range query {
+		if slices.Contains(listOptions, key) || slices.Contains(options, key) {
+			continue
+		}
+		name, op, value := splitFilterKey(key, strings.Join(values, ","))
+
+		field, ok := fields[name]
+		if !ok {
+			names := slices.Sorted(maps.Keys(fields))
+			return nil, fmt.Errorf("Unknown filter %q, expected one of %s", name, strings.Join(names, ", "))
+		}
+
+		f := filter[T]{field: field, op: op}
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				f.values = append(f.values, store.Slug(v))
+			}
+		}
+		if len(f.values) == 0 {
+			return nil, fmt.Errorf("Missing value for filter %q", name)
+		}
+
+		if field.number != nil {
+			for _, v := range f.values {
+				n, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid number %q for filter %q", v, name)
+				}
+				f.numbers = append(f.numbers, n)
+			}
+		} else if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("Filter %q does not support %s", name, op)
+		}
+
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+func splitFilterKey(key, value string) (name, op, rest string) {
+	i := strings.IndexAny(key, "<>!")
+	if i < 0 {
+		return key, "=", value
+	}
+
+	name, suffix := key[:i], key[i:]
+	switch {
+	case len(suffix) == 1 && value != "":
+		return name, suffix + "=", value
+	case suffix[0] != '!' && value == "":
+		return name, suffix[:1], suffix[1:]
+	}
+	return key, "=", value
+}
+
+func matchFilters[T any](item T, filters []filter[T]) bool {
+	for _, f := range filters {
+		if !f.match(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f filter[T]) match(item T) bool {
+	if f.field.number != nil {
+		n := f.field.number(item)
+		switch f.op {
+		case ">=":
+			return n >= f.numbers[0]
+		case "<=":
+			return n <= f.numbers[0]
+		case ">":
+			return n > f.numbers[0]
+		case "<":
+			return n < f.numbers[0]
+		}
+		for _, want := range f.numbers {
+			if n == want {
+				return f.op == "="
+			}
+		}
+		return f.op == "!="
+	}
+
+	for _, v := range f.field.text(item) {
+		for _, want := range f.values {
+			if store.Slug(v) == want {
+				return f.op == "="
+			}
+		}
+	}
+	return f.op == "!="
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"api/models"
+	"api/store"
+)
+
+var materialFilters = map[string]filterField[models.Material]{
+	"name":     {text: func(m models.Material) []string { return []string{m.Name} }},
+	"category": {text: func(m models.Material) []string { return []string{m.Category} }},
+	"source":   {text: func(m models.Material) []string { return m.Sources }},
+	"rarity":   {number: func(m models.Material) float64 { return float64(m.Rarity) }},
+}
+
+func ListMaterialsHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filters, err := parseFilters(c.Request.URL.Query(), materialFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		var matched []models.Material
+		for material := range s.EachMaterial() {
+			if matchFilters(material, filters) {
+				matched = append(matched, material)
+			}
+		}
+		if err := sortItems(matched, c.Query("sort"), materialFilters); err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		writeList(c, "materials", matched, func(material models.Material) string { return material.Name })
+	}
+}
+
+func GetMaterialHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		material, ok := s.Material(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindMaterials, c.Param("name"), "Material not found")
+			return
+		}
+		writeItem(c, material)
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"api/calc"
+	"api/store"
+)
+
+func PlannerHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var in calc.PlanInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			BadRequestHandler(c, "Invalid JSON body")
+			return
+		}
+
+		result, err := calc.Plan(s, in)
+		if err != nil {
+			InvalidInputHandler(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
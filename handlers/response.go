@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeList writes items under key along with their total count. By default
+// only their names are listed; ?expand=true (or ?full=1) lists the full
+// objects and ?fields=a,b lists objects reduced to those fields. ?limit and
+// ?offset select a page and add a link to the next one.
+func writeList[T any](c *gin.Context, key string, items []T, name func(T) string) {
+	fields, err := parseFields[T](c)
+	if err != nil {
+		BadRequestHandler(c, err.Error())
+		return
+	}
+
+	response := gin.H{"total": len(items)}
+	items, err = paginate(c, items, response)
+	if err != nil {
+		BadRequestHandler(c, err.Error())
+		return
+	}
+
+	if fields == nil && !isTrue(c.Query("expand")) && !isTrue(c.Query("full")) {
+		names := []string{}
+		for _, item := range items {
+			names = append(names, name(item))
+		}
+		response[key] = names
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if fields == nil {
+		if items == nil {
+			items = []T{}
+		}
+		response[key] = items
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	projected := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		p, err := project(item, fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		projected = append(projected, p)
+	}
+	response[key] = projected
+	c.JSON(http.StatusOK, response)
+}
+
+// MaxLimit is the largest page ?limit may ask for.
+const MaxLimit = 1000
+
+// paginate returns the page of items selected by ?limit and ?offset and
+// records the paging details in response. Without a limit every item from
+// offset onwards is returned.
+func paginate[T any](c *gin.Context, items []T, response gin.H) ([]T, error) {
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := queryInt(c, "limit", -1)
+	if err != nil {
+		return nil, err
+	}
+	if c.Query("limit") != "" && (limit < 1 || limit > MaxLimit) {
+		return nil, fmt.Errorf("Invalid limit %q, expected 1-%d", c.Query("limit"), MaxLimit)
+	}
+
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+
+	if limit < 0 {
+		if offset > 0 {
+			response["offset"] = offset
+		}
+		return items, nil
+	}
+
+	response["offset"] = offset
+	response["limit"] = limit
+	// The next offset is always past this one, so following next links
+	// cannot loop.
+	if limit < len(items) {
+		items = items[:limit]
+
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("offset", strconv.Itoa(offset+limit))
+		next.RawQuery = query.Encode()
+		response["next"] = next.RequestURI()
+	}
+	return items, nil
+}
+
+func queryInt(c *gin.Context, key string, fallback int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid %s %q", key, value)
+	}
+	return n, nil
+}
+
+// writeItem writes a single object, reduced to ?fields when given.
+func writeItem[T any](c *gin.Context, item T) {
+	fields, err := parseFields[T](c)
+	if err != nil {
+		BadRequestHandler(c, err.Error())
+		return
+	}
+
+	if fields == nil {
+		c.JSON(http.StatusOK, item)
+		return
+	}
+
+	p, err := project(item, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func isTrue(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// parseFields reads ?fields and maps each entry to the JSON name of a field of
+// T. Names are matched case-insensitively since query values are lowercased.
+func parseFields[T any](c *gin.Context) ([]string, error) {
+	value := c.Query("fields")
+	if value == "" {
+		return nil, nil
+	}
+
+	known := jsonFields(reflect.TypeFor[T]())
+
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, ok := known[strings.ToLower(field)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown field %q", field)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// jsonFields maps the lowercased JSON names of t's fields to their names.
+func jsonFields(t reflect.Type) map[string]string {
+	fields := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[strings.ToLower(name)] = name
+	}
+	return fields
+}
+
+func project(item interface{}, fields []string) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected, nil
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"api/models"
+	"api/store"
+)
+
+type testItem struct {
+	Name   string `json:"name"`
+	Rarity int    `json:"rarity"`
+	Tags   string `json:"tags,omitempty"`
+}
+
+var testItemFields = map[string]filterField[testItem]{
+	"name":   {text: func(i testItem) []string { return []string{i.Name} }},
+	"rarity": {number: func(i testItem) float64 { return float64(i.Rarity) }},
+}
+
+var testItems = []testItem{
+	{Name: "Alpha", Rarity: 5},
+	{Name: "Beta", Rarity: 4},
+	{Name: "Gamma", Rarity: 5},
+	{Name: "Delta", Rarity: 3},
+	{Name: "Epsilon", Rarity: 4},
+}
+
+type listResponse struct {
+	Total  int               `json:"total"`
+	Offset int               `json:"offset"`
+	Limit  int               `json:"limit"`
+	Next   string            `json:"next"`
+	Items  []json.RawMessage `json:"items"`
+}
+
+func serveList(t *testing.T, target string) (int, listResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/items", func(c *gin.Context) {
+		writeList(c, "items", slices.Clone(testItems), func(i testItem) string { return i.Name })
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	var body listResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Unmarshal() = %v", err)
+		}
+	}
+	return w.Code, body
+}
+
+func names(t *testing.T, items []json.RawMessage) []string {
+	t.Helper()
+	var out []string
+	for _, item := range items {
+		var name string
+		if err := json.Unmarshal(item, &name); err != nil {
+			t.Fatalf("Unmarshal(%s) = %v", item, err)
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		target string
+		code   int
+		names  []string
+		next   string
+	}{
+		{"/items", http.StatusOK, []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"}, ""},
+		{"/items?limit=2", http.StatusOK, []string{"Alpha", "Beta"}, "/items?limit=2&offset=2"},
+		{"/items?limit=2&offset=2", http.StatusOK, []string{"Gamma", "Delta"}, "/items?limit=2&offset=4"},
+		{"/items?limit=2&offset=4", http.StatusOK, []string{"Epsilon"}, ""},
+		{"/items?limit=5", http.StatusOK, []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"}, ""},
+		{"/items?offset=3", http.StatusOK, []string{"Delta", "Epsilon"}, ""},
+		{"/items?offset=9", http.StatusOK, nil, ""},
+		{"/items?limit=0", http.StatusBadRequest, nil, ""},
+		{"/items?limit=-1", http.StatusBadRequest, nil, ""},
+		{"/items?limit=1001", http.StatusBadRequest, nil, ""},
+		{"/items?limit=two", http.StatusBadRequest, nil, ""},
+		{"/items?offset=-1", http.StatusBadRequest, nil, ""},
+	}
+	for _, tt := range tests {
+		code, body := serveList(t, tt.target)
+		if code != tt.code {
+			t.Errorf("GET %s = %d, want %d", tt.target, code, tt.code)
+			continue
+		}
+		if code != http.StatusOK {
+			continue
+		}
+		if body.Total != len(testItems) {
+			t.Errorf("GET %s: total = %d, want %d", tt.target, body.Total, len(testItems))
+		}
+		if got := names(t, body.Items); !slices.Equal(got, tt.names) {
+			t.Errorf("GET %s = %v, want %v", tt.target, got, tt.names)
+		}
+		if body.Next != tt.next {
+			t.Errorf("GET %s: next = %q, want %q", tt.target, body.Next, tt.next)
+		}
+	}
+}
+
+func TestPaginateNextEnds(t *testing.T) {
+	target, pages := "/items?limit=1", 0
+	for target != "" {
+		pages++
+		if pages > len(testItems) {
+			t.Fatalf("next links did not end after %d pages", len(testItems))
+		}
+		_, body := serveList(t, target)
+		target = body.Next
+	}
+	if pages != len(testItems) {
+		t.Errorf("followed %d pages, want %d", pages, len(testItems))
+	}
+}
+
+func TestWriteListFields(t *testing.T) {
+	code, body := serveList(t, "/items?fields=rarity&limit=1")
+	if code != http.StatusOK {
+		t.Fatalf("GET = %d", code)
+	}
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(body.Items[0], &item); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	if len(item) != 1 || string(item["rarity"]) != "5" {
+		t.Errorf("fields=rarity gave %v", item)
+	}
+
+	if code, _ := serveList(t, "/items?fields=power"); code != http.StatusBadRequest {
+		t.Errorf("unknown field = %d, want 400", code)
+	}
+	if code, body := serveList(t, "/items?expand=true&limit=1"); code != http.StatusOK || string(body.Items[0]) != `{"name":"Alpha","rarity":5}` {
+		t.Errorf("expand=true gave %d %s", code, body.Items)
+	}
+	if code, body := serveList(t, "/items?expand=false&limit=1"); code != http.StatusOK || string(body.Items[0]) != `"Alpha"` {
+		t.Errorf("expand=false gave %d %s", code, body.Items)
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+		err   bool
+	}{
+		{"", []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"}, false},
+		{"rarity=5", []string{"Alpha", "Gamma"}, false},
+		{"rarity=4,5", []string{"Alpha", "Beta", "Gamma", "Epsilon"}, false},
+		{"rarity=4&rarity=3", []string{"Beta", "Delta", "Epsilon"}, false},
+		{"rarity!=5", []string{"Beta", "Delta", "Epsilon"}, false},
+		{"rarity>=4", []string{"Alpha", "Beta", "Gamma", "Epsilon"}, false},
+		{"rarity<4", []string{"Delta"}, false},
+		{"rarity>4", []string{"Alpha", "Gamma"}, false},
+		{"name=beta", []string{"Beta"}, false},
+		{"name=beta&rarity=5", nil, false},
+		{"limit=2&sort=name&expand=true", []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"}, false},
+		{"power=9", nil, true},
+		{"rarity=high", nil, true},
+		{"rarity=", nil, true},
+		{"name>=beta", nil, true},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) = %v", tt.query, err)
+		}
+		filters, err := parseFilters(query, testItemFields)
+		if (err != nil) != tt.err {
+			t.Errorf("parseFilters(%q) error = %v, want error %v", tt.query, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var got []string
+		for _, item := range testItems {
+			if matchFilters(item, filters) {
+				got = append(got, item.Name)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseFilters(%q) matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseFiltersOptions(t *testing.T) {
+	query := url.Values{"q": {"x"}}
+	if _, err := parseFilters(query, testItemFields); err == nil {
+		t.Error("parseFilters accepted an unknown parameter")
+	}
+	if _, err := parseFilters(query, testItemFields, "q"); err != nil {
+		t.Errorf("parseFilters with option q = %v", err)
+	}
+}
+
+func TestSortItems(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+		err  bool
+	}{
+		{"", []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"}, false},
+		{"name", []string{"Alpha", "Beta", "Delta", "Epsilon", "Gamma"}, false},
+		{"-name", []string{"Gamma", "Epsilon", "Delta", "Beta", "Alpha"}, false},
+		// Equal rarities keep their order.
+		{"rarity", []string{"Delta", "Beta", "Epsilon", "Alpha", "Gamma"}, false},
+		{"-rarity,name", []string{"Alpha", "Gamma", "Beta", "Epsilon", "Delta"}, false},
+		{"-rarity,-name", []string{"Gamma", "Alpha", "Epsilon", "Beta", "Delta"}, false},
+		{"power", nil, true},
+	}
+	for _, tt := range tests {
+		items := slices.Clone(testItems)
+		err := sortItems(items, tt.spec, testItemFields)
+		if (err != nil) != tt.err {
+			t.Errorf("sortItems(%q) error = %v, want error %v", tt.spec, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var got []string
+		for _, item := range items {
+			got = append(got, item.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("sortItems(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestListWeaponTypesHandler(t *testing.T) {
+	s := store.NewMemory(store.Data{Weapons: map[string][]models.Weapon{
+		"Sword":     {{Name: "Emerald of Genesis", Type: "Sword", Rarity: 5}},
+		"Gauntlets": {{Name: "Abyss Surges", Type: "Gauntlets", Rarity: 5}},
+		"Pistols":   {{Name: "Static Mist", Type: "Pistols", Rarity: 5}},
+	}})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/weapons", ListWeaponTypesHandler(s))
+
+	tests := []struct {
+		target string
+		key    string
+		want   []string
+		next   string
+	}{
+		{"/weapons", "types", []string{"Gauntlets", "Pistols", "Sword"}, ""},
+		{"/weapons?limit=2", "types", []string{"Gauntlets", "Pistols"}, "/weapons?limit=2&offset=2"},
+		{"/weapons?limit=2&offset=2", "types", []string{"Sword"}, ""},
+		{"/weapons?expand=false", "types", []string{"Gauntlets", "Pistols", "Sword"}, ""},
+		{"/weapons?expand=true&sort=name", "weapons", nil, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s = %d", tt.target, w.Code)
+			continue
+		}
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Unmarshal() = %v", err)
+		}
+		if _, ok := body[tt.key]; !ok {
+			t.Errorf("GET %s has no %q: %s", tt.target, tt.key, w.Body)
+			continue
+		}
+		if tt.want != nil {
+			var got []string
+			json.Unmarshal(body[tt.key], &got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GET %s = %v, want %v", tt.target, got, tt.want)
+			}
+		}
+		var next string
+		json.Unmarshal(body["next"], &next)
+		if next != tt.next {
+			t.Errorf("GET %s: next = %q, want %q", tt.target, next, tt.next)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/weapons?limit=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GET /weapons?limit=0 = %d, want 400", w.Code)
+	}
+}
+
+func TestListHandlersRejectUnknownFilters(t *testing.T) {
+	s := store.NewMemory(store.Data{
+		Sonatas:  []models.Sonata{{Name: "Freezing Frost"}, {Name: "Molten Rift"}},
+		Substats: []models.Substat{{Name: "ATK", Min: 30, Max: 60}},
+	})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/echoes/sonatas", ListSonatasHandler(s))
+	r.GET("/echoes/substats", ListSubstatsHandler(s))
+	r.GET("/attributes", ListAttributesHandler(s))
+
+	tests := []struct {
+		target string
+		code   int
+	}{
+		{"/echoes/sonatas?name=molten-rift", http.StatusOK},
+		{"/echoes/sonatas?rarity=5", http.StatusBadRequest},
+		{"/echoes/substats?name=atk&limit=1", http.StatusOK},
+		{"/echoes/substats?cost=4", http.StatusBadRequest},
+		{"/attributes?expand=true", http.StatusOK},
+		{"/attributes?element=fusion", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if w.Code != tt.code {
+			t.Errorf("GET %s = %d, want %d: %s", tt.target, w.Code, tt.code, w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echoes/sonatas?name=molten-rift", nil))
+	var body struct {
+		Sonatas []string `json:"sonatas"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if !slices.Equal(body.Sonatas, []string{"Molten Rift"}) {
+		t.Errorf("name=molten-rift = %v", body.Sonatas)
+	}
+}
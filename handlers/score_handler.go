@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"api/calc"
+	"api/store"
+)
+
+func ScoreEchoHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var in calc.ScoreInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			BadRequestHandler(c, "Invalid JSON body")
+			return
+		}
+
+		s := s.Snapshot()
+		result, err := calc.Score(s.Stats(), s.Substats(), in)
+		if err != nil {
+			InvalidInputHandler(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"api/store"
+)
+
+var searchTypes = map[string]bool{
+	"character": true,
+	"weapon":    true,
+	"echo":      true,
+	"sonata":    true,
+}
+
+func SearchHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			BadRequestHandler(c, "Missing search query")
+			return
+		}
+
+		var types []string
+		if value := c.Query("type"); value != "" {
+			for _, t := range strings.Split(value, ",") {
+				if !searchTypes[t] {
+					BadRequestHandler(c, "Invalid search type")
+					return
+				}
+				types = append(types, t)
+			}
+		}
+
+		var hits []store.SearchHit
+		for _, hit := range s.Search(query) {
+			if types == nil || slices.Contains(types, hit.Type) {
+				hits = append(hits, hit)
+			}
+		}
+
+		response := gin.H{"query": query, "total": len(hits)}
+		hits, err := paginate(c, hits, response)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+		if hits == nil {
+			hits = []store.SearchHit{}
+		}
+		response["results"] = hits
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"api/calc"
+	"api/store"
+)
+
+type simulateRequest struct {
+	Cost     int     `json:"cost"`
+	MainStat string  `json:"mainStat"`
+	Level    *int    `json:"level"`
+	Seed     *uint64 `json:"seed"`
+	Count    int     `json:"count"`
+}
+
+// SimulateEchoHandler rolls one echo, or a batch of count echoes summarised as
+// distributions. Level defaults to the maximum and a random seed is chosen
+// and returned when none is given.
+func SimulateEchoHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req simulateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BadRequestHandler(c, "Invalid JSON body")
+			return
+		}
+
+		in := calc.SimulateInput{
+			Cost:     req.Cost,
+			MainStat: req.MainStat,
+			Level:    calc.MaxEchoLevel,
+			Seed:     rand.Uint64(),
+			Count:    req.Count,
+		}
+		if req.Level != nil {
+			in.Level = *req.Level
+		}
+		if req.Seed != nil {
+			in.Seed = *req.Seed
+		}
+
+		s := s.Snapshot()
+		result, err := calc.Simulate(s.Stats(), s.Substats(), in)
+		if err != nil {
+			InvalidInputHandler(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -2,30 +2,68 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
-	"net/http"
-	"strings"
+	"api/calc"
 	"api/models"
+	"api/store"
 )
 
-func ListSonatasHandler(sonatas []models.Sonata) gin.HandlerFunc {
+var sonataFilters = map[string]filterField[models.Sonata]{
+	"name": {text: func(s models.Sonata) []string { return []string{s.Name} }},
+}
+
+func ListSonatasHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var sonataNames []string
-		for _, sonata := range sonatas {
-			sonataNames = append(sonataNames, sonata.Name)
+		filters, err := parseFilters(c.Request.URL.Query(), sonataFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		var matched []models.Sonata
+		for _, sonata := range s.Sonatas() {
+			if matchFilters(sonata, filters) {
+				matched = append(matched, sonata)
+			}
 		}
-		c.JSON(http.StatusOK, gin.H{"sonatas": sonataNames})
+		writeList(c, "sonatas", matched, func(sonata models.Sonata) string { return sonata.Name })
 	}
 }
 
-func GetSonataHandler(sonatas []models.Sonata) gin.HandlerFunc {
+func GetSonataHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		name := strings.ReplaceAll(strings.ToLower(c.Param("name")), "_", " ")
-		for _, sonata := range sonatas {
-			if strings.ToLower(sonata.Name) == name {
-				c.JSON(http.StatusOK, sonata)
-				return
+		sonata, ok := s.Sonata(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindSonatas, c.Param("name"), "Sonata not found")
+			return
+		}
+		writeItem(c, sonata)
+	}
+}
+func SonataEchoesHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := s.Snapshot()
+		sonata, ok := s.Sonata(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindSonatas, c.Param("name"), "Sonata not found")
+			return
+		}
+
+		filters, err := parseFilters(c.Request.URL.Query(), echoFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		var echoes []models.Echo
+		for _, echo := range calc.SonataEchoes(s, sonata) {
+			if matchFilters(echo, filters) {
+				echoes = append(echoes, echo)
 			}
 		}
-		NotFoundHandler(c, "Sonata not found")
+		if err := sortItems(echoes, c.Query("sort"), echoFilters); err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+		writeList(c, "echoes", echoes, func(echo models.Echo) string { return echo.Name })
 	}
-}
\ No newline at end of file
+}
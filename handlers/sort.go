@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"api/store"
+)
+
+// sortItems orders items by a comma separated list of fields, each optionally
+// prefixed with '-' for descending order. Entries that compare equal keep
+// their relative order so results stay stable between requests.
+func sortItems[T any](items []T, spec string, fields map[string]filterField[T]) error {
+	if spec == "" {
+		return nil
+	}
+
+	type key struct {
+		field filterField[T]
+		desc  bool
+	}
+
+	var keys []key
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if name == "" {
+			continue
+		}
+
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("Cannot sort by %q", name)
+		}
+		keys = append(keys, key{field: field, desc: desc})
+	}
+
+	slices.SortStableFunc(items, func(a, b T) int {
+		for _, k := range keys {
+			var n int
+			if k.field.number != nil {
+				n = cmp.Compare(k.field.number(a), k.field.number(b))
+			} else {
+				n = cmp.Compare(sortText(k.field.text(a)), sortText(k.field.text(b)))
+			}
+			if k.desc {
+				n = -n
+			}
+			if n != 0 {
+				return n
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+func sortText(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return store.Slug(values[0])
+}
@@ -2,30 +2,46 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
-	"net/http"
-	"strings"
 	"api/models"
+	"api/store"
 )
 
-func ListStatsHandler(stats []models.Stat) gin.HandlerFunc {
+var statFilters = map[string]filterField[models.Stat]{
+	"cost": {number: func(s models.Stat) float64 { return float64(s.Cost) }},
+	"primary": {text: func(s models.Stat) []string {
+		var names []string
+		for _, primary := range s.Primary {
+			names = append(names, primary.Name)
+		}
+		return names
+	}},
+}
+
+func ListStatsHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var statNames []string
-		for _, stat := range stats {
-			statNames = append(statNames, stat.Name)
+		filters, err := parseFilters(c.Request.URL.Query(), statFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{"stats": statNames})
+
+		var matched []models.Stat
+		for stat := range s.EachStat() {
+			if matchFilters(stat, filters) {
+				matched = append(matched, stat)
+			}
+		}
+		writeList(c, "stats", matched, func(stat models.Stat) string { return stat.Name })
 	}
 }
 
-func GetStatHandler(stats []models.Stat) gin.HandlerFunc {
+func GetStatHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		name := strings.ReplaceAll(strings.ToLower(c.Param("name")), "_", " ")
-		for _, stat := range stats {
-			if strings.ToLower(stat.Name) == name {
-				c.JSON(http.StatusOK, stat)
-				return
-			}
+		stat, ok := s.Stat(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindStats, c.Param("name"), "Stat not found")
+			return
 		}
-		NotFoundHandler(c, "Stat not found")
+		writeItem(c, stat)
 	}
 }
\ No newline at end of file
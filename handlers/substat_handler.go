@@ -2,30 +2,39 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
-	"net/http"
-	"strings"
 	"api/models"
+	"api/store"
 )
 
-func ListSubstatsHandler(substats []models.Substat) gin.HandlerFunc {
+var substatFilters = map[string]filterField[models.Substat]{
+	"name": {text: func(s models.Substat) []string { return []string{s.Name} }},
+}
+
+func ListSubstatsHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var substatNames []string
-		for _, substat := range substats {
-			substatNames = append(substatNames, substat.Name)
+		filters, err := parseFilters(c.Request.URL.Query(), substatFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		var matched []models.Substat
+		for _, substat := range s.Substats() {
+			if matchFilters(substat, filters) {
+				matched = append(matched, substat)
+			}
 		}
-		c.JSON(http.StatusOK, gin.H{"substats": substatNames})
+		writeList(c, "substats", matched, func(substat models.Substat) string { return substat.Name })
 	}
 }
 
-func GetSubstatHandler(substats []models.Substat) gin.HandlerFunc {
+func GetSubstatHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		name := strings.ReplaceAll(strings.ToLower(c.Param("name")), "_", " ")
-		for _, substat := range substats {
-			if strings.ToLower(substat.Name) == name {
-				c.JSON(http.StatusOK, substat)
-				return
-			}
+		substat, ok := s.Substat(c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindSubstats, c.Param("name"), "Substat not found")
+			return
 		}
-		NotFoundHandler(c, "Substat not found")
+		writeItem(c, substat)
 	}
 }
\ No newline at end of file
@@ -3,85 +3,208 @@ package handlers
 import (
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"fmt"
-	"io"
+	"strconv"
 	"strings"
+	"api/assets"
+	"api/calc"
 	"api/models"
+	"api/store"
 )
 
-func ListWeaponTypesHandler(weapons map[string][]models.Weapon) gin.HandlerFunc {
+var weaponFilters = map[string]filterField[models.Weapon]{
+	"name":    {text: func(w models.Weapon) []string { return []string{w.Name} }},
+	"type":    {text: func(w models.Weapon) []string { return []string{w.Type} }},
+	"substat": {text: func(w models.Weapon) []string { return []string{w.Stats.Substat.Name} }},
+	"rarity":  {number: func(w models.Weapon) float64 { return float64(w.Rarity) }},
+}
+
+// ListWeaponTypesHandler lists the weapon types, or when filters or list
+// options are given, the matching weapons across every type.
+func ListWeaponTypesHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var weaponTypes []string
-		for weaponType := range weapons {
-			weaponTypes = append(weaponTypes, weaponType)
+		filters, err := parseFilters(c.Request.URL.Query(), weaponFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		if len(filters) == 0 && !isTrue(c.Query("expand")) && !isTrue(c.Query("full")) && c.Query("fields") == "" && c.Query("sort") == "" {
+			types := s.WeaponTypes()
+			response := gin.H{"total": len(types)}
+			types, err := paginate(c, types, response)
+			if err != nil {
+				BadRequestHandler(c, err.Error())
+				return
+			}
+			response["types"] = types
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		var matched []models.Weapon
+		for _, weapon := range s.EachWeapon() {
+			if matchFilters(weapon, filters) {
+				matched = append(matched, weapon)
+			}
 		}
-		c.JSON(http.StatusOK, gin.H{"types": weaponTypes})
+		if err := sortItems(matched, c.Query("sort"), weaponFilters); err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		writeList(c, "weapons", matched, weaponName)
 	}
 }
 
-func ListWeaponsHandler(weapons map[string][]models.Weapon) gin.HandlerFunc {
+func ListWeaponsHandler(s store.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		weaponType := strings.ToLower(c.Param("type"))
-
-		weaponsOfType, ok := weapons[weaponType]
+		weaponsOfType, ok := s.Weapons(c.Param("type"))
 		if !ok {
-			NotFoundHandler(c, "Weapon type not found")
+			SuggestNotFoundHandler(c, s, store.KindWeaponTypes, c.Param("type"), "Weapon type not found")
+			return
+		}
+
+		filters, err := parseFilters(c.Request.URL.Query(), weaponFilters)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
 			return
 		}
 
-		var weaponNames []string
+		var matched []models.Weapon
 		for _, weapon := range weaponsOfType {
-			weaponNames = append(weaponNames, weapon.Name)
+			if matchFilters(weapon, filters) {
+				matched = append(matched, weapon)
+			}
 		}
 
-		c.JSON(http.StatusOK, gin.H{"weapons": weaponNames})
+		if err := sortItems(matched, c.Query("sort"), weaponFilters); err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+
+		writeList(c, "weapons", matched, weaponName)
 	}
 }
 
-func GetWeaponHandler(weapons map[string][]models.Weapon) gin.HandlerFunc {
-    return func(c *gin.Context) {
-        weaponType := strings.ToLower(c.Param("type"))
-        weaponName := strings.ToLower(strings.ReplaceAll(c.Param("name"), "_", " "))
-
-        weaponsOfType, ok := weapons[weaponType]
-        if !ok {
-            NotFoundHandler(c, "Weapon type not found")
-            return
-        }
-
-        for i := range weaponsOfType {
-            if strings.ToLower(weaponsOfType[i].Name) == weaponName {
-                c.JSON(http.StatusOK, weaponsOfType[i])
-                return
-            }
-        }
-
-        NotFoundHandler(c, "Weapon not found")
-    }
+func GetWeaponHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := s.Snapshot()
+		if _, ok := s.Weapons(c.Param("type")); !ok {
+			SuggestNotFoundHandler(c, s, store.KindWeaponTypes, c.Param("type"), "Weapon type not found")
+			return
+		}
+
+		weapon, ok := s.Weapon(c.Param("type"), c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindWeapons, c.Param("name"), "Weapon not found")
+			return
+		}
+
+		if value := c.Query("refinement"); value != "" {
+			refinement, err := strconv.Atoi(value)
+			if err != nil {
+				BadRequestHandler(c, "Invalid refinement")
+				return
+			}
+			rendered, err := calc.RenderWeaponSkill(weapon, refinement)
+			if err != nil {
+				InvalidInputHandler(c, err)
+				return
+			}
+			weapon.Skill.Refinement = refinement
+			weapon.Skill.Rendered = rendered
+		}
+
+		writeItem(c, weapon)
+	}
 }
 
-func WeaponIconHandler(c *gin.Context) {
-	weaponType := strings.ToLower(c.Param("type"))
-	weaponName := strings.ToLower(strings.ReplaceAll(c.Param("name"), "_", " "))
-	weaponName = strings.ReplaceAll(weaponName, " ", "_")
+// WeaponRefinementsHandler renders the weapon's skill at every refinement.
+func WeaponRefinementsHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := s.Snapshot()
+		if _, ok := s.Weapons(c.Param("type")); !ok {
+			SuggestNotFoundHandler(c, s, store.KindWeaponTypes, c.Param("type"), "Weapon type not found")
+			return
+		}
+
+		weapon, ok := s.Weapon(c.Param("type"), c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindWeapons, c.Param("name"), "Weapon not found")
+			return
+		}
+
+		var refinements []gin.H
+		for refinement := 1; refinement <= calc.MaxRefinement; refinement++ {
+			rendered, err := calc.RenderWeaponSkill(weapon, refinement)
+			if err != nil {
+				InvalidInputHandler(c, err)
+				return
+			}
+			entry := gin.H{"refinement": refinement, "description": rendered}
+			// Refinements the data lacks values for keep their placeholders
+			// and say which ones are missing.
+			if missing := calc.MissingWeaponValues(weapon, refinement); len(missing) > 0 {
+				entry["incomplete"] = true
+				entry["missing"] = missing
+			}
+			refinements = append(refinements, entry)
+		}
 
-	remoteURL := fmt.Sprintf("%sweapons/%s/%s.png", cdnURL, weaponType, weaponName)
-	resp, err := http.Get(remoteURL)
-	if err != nil {
-		NotFoundHandler(c, "Failed to fetch file")
-		return
+		c.JSON(http.StatusOK, gin.H{
+			"name":        weapon.Name,
+			"skill":       weapon.Skill.Name,
+			"refinements": refinements,
+		})
 	}
-	defer resp.Body.Close()
+}
+
+func WeaponStatsHandler(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := s.Snapshot()
+		if _, ok := s.Weapons(c.Param("type")); !ok {
+			SuggestNotFoundHandler(c, s, store.KindWeaponTypes, c.Param("type"), "Weapon type not found")
+			return
+		}
+
+		weapon, ok := s.Weapon(c.Param("type"), c.Param("name"))
+		if !ok {
+			SuggestNotFoundHandler(c, s, store.KindWeapons, c.Param("name"), "Weapon not found")
+			return
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		NotFoundHandler(c, "Failed to fetch file")
-		return
+		if weapon.Stats.Attack == 0 && len(weapon.Stats.Levels) == 0 {
+			NotFoundHandler(c, "No stats known for this weapon")
+			return
+		}
+
+		if c.Query("level") == "" {
+			c.JSON(http.StatusOK, gin.H{"name": weapon.Name, "stats": weapon.Stats})
+			return
+		}
+
+		level, err := queryInt(c, "level", 0)
+		if err != nil {
+			BadRequestHandler(c, err.Error())
+			return
+		}
+		stats, err := calc.WeaponStatsAt(weapon, level, isTrue(c.Query("ascended")))
+		if err != nil {
+			InvalidInputHandler(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, stats)
 	}
+}
+
+func weaponName(weapon models.Weapon) string { return weapon.Name }
+
+func WeaponIconHandler(images *assets.Images) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		weaponType := strings.ToLower(c.Param("type"))
+		weaponName := strings.ToLower(strings.ReplaceAll(c.Param("name"), "_", " "))
+		weaponName = strings.ReplaceAll(weaponName, " ", "_")
 
-	c.Header("Content-Type", "image/png")
-	_, err = io.Copy(c.Writer, resp.Body)
-	if err != nil {
-		NotFoundHandler(c, "Failed to serve file")
-		return
+		serveAsset(c, images, assets.WeaponIcon(weaponType, weaponName), "Failed to fetch file")
 	}
 }
\ No newline at end of file
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"api/store"
+)
+
+func TestWeaponRefinementsHandlerIncomplete(t *testing.T) {
+	s, err := store.LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON() = %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/weapons/:type/:name/refinements", WeaponRefinementsHandler(s))
+
+	// The data only has four refinement values for this weapon's skill.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/weapons/gauntlets/gauntlets_of_night/refinements", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET = %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Refinements []struct {
+			Refinement  int    `json:"refinement"`
+			Description string `json:"description"`
+			Incomplete  bool   `json:"incomplete"`
+			Missing     []int  `json:"missing"`
+		} `json:"refinements"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	if len(body.Refinements) != 5 {
+		t.Fatalf("got %d refinements, want 5", len(body.Refinements))
+	}
+	for _, refinement := range body.Refinements[:4] {
+		if refinement.Incomplete {
+			t.Errorf("refinement %d flagged incomplete: %q", refinement.Refinement, refinement.Description)
+		}
+	}
+	if last := body.Refinements[4]; !last.Incomplete || len(last.Missing) != 1 || last.Missing[0] != 0 {
+		t.Errorf("refinement 5 = %+v, want it flagged as missing {0}", last)
+	}
+}
@@ -1,116 +1,381 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"api/apikeys"
+	"api/assets"
 	"api/handlers"
-	"api/models"
+	"api/ratelimit"
+	"api/store"
 	"api/utils"
+	"api/validation"
 )
 
 var docsURL = "https://github.com/whosneksio/resonance.rest/blob/main/README.md"
 
 func main() {
-	r := gin.Default()
-
-	r.Use(utils.LowercaseMiddleware())
-	r.Use(utils.RateLimitMiddleware())
+	dataDir := utils.GetEnv("DATA_DIR", "data")
 
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	r.Use(cors.New(config))
+	keysFile := utils.GetEnv("API_KEYS_FILE", "keys.json")
 
-	// Load data
-	characters, err := utils.LoadCharacters("data/characters")
-	if err != nil {
-		log.Fatalf("Error loading characters: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(dataDir))
 	}
-
-	attributes, err := utils.LoadAttributes("data/attributes.json")
-	if err != nil {
-		log.Fatalf("Error loading attributes: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(runKeys(keysFile, os.Args[2:]))
 	}
 
-	weapons, err := utils.LoadWeapons("data/weapons.json")
-	if err != nil {
-		log.Fatalf("Error loading weapons: %v", err)
-	}
+	// ctx ends on SIGINT or SIGTERM, stopping the server and the watchers.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	echoes, err := utils.LoadEchoes("data/echoes.json")
+	keys, err := apikeys.Open(keysFile)
 	if err != nil {
-		log.Fatalf("Error loading echoes: %v", err)
+		log.Fatalf("Error loading API keys: %v", err)
 	}
+	// The usage is written out once more after the server has shut down, so
+	// requests still draining when ctx ends are counted too.
+	keysDone := make(chan struct{})
+	go func() {
+		defer close(keysDone)
+		keys.Watch(ctx, utils.GetEnvDuration("API_KEYS_WATCH_INTERVAL", 5*time.Second))
+	}()
 
-	sonatas, err := utils.LoadSonatas("data/sonatas.json")
-	if err != nil {
-		log.Fatalf("Error loading sonatas: %v", err)
-	}
+	r := gin.Default()
+
+	r.Use(utils.LowercaseMiddleware())
+	limiter := newRateLimiter()
+	go limiter.Run(ctx, utils.GetEnvDuration("RATE_LIMIT_EVICT_INTERVAL", time.Minute))
+	r.Use(utils.RateLimitMiddleware(limiter, keys))
+
+	config := cors.DefaultConfig()
+	config.AllowAllOrigins = true
+	config.AddAllowHeaders(apikeys.Header)
+	config.AddExposeHeaders("X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After",
+		"X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset")
+	r.Use(cors.New(config))
 
-	stats, err := utils.LoadStats("data/echoes/stats.json")
+	// Load data
+	data, err := store.NewReloader(func() (*store.Memory, error) {
+		return store.LoadJSON(dataDir)
+	}, func(m *store.Memory) error {
+		if err := m.Check(); err != nil {
+			return err
+		}
+		for _, issue := range validation.Validate(m, dataDir) {
+			log.Printf("Data issue: %s", issue)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Fatalf("Error loading stats: %v", err)
+		log.Fatalf("Error loading data: %v", err)
 	}
 
-	substats, err := utils.LoadSubstats("data/echoes/substats.json")
-	if err != nil {
-		log.Fatalf("Error loading substats: %v", err)
+	go reloadOnSignal(data)
+	if interval := utils.GetEnvDuration("DATA_WATCH_INTERVAL", 5*time.Second); interval > 0 {
+		go data.Watch(ctx, dataDir, interval)
 	}
 
-	codes, err := utils.LoadCodes("data/codes.json")
+	images, err := newImages()
 	if err != nil {
-		log.Fatalf("Error loading codes: %v", err)
+		log.Fatalf("Error setting up assets: %v", err)
 	}
 
-	setupRoutes(r, characters, attributes, weapons, echoes, sonatas, stats, substats, codes)
+	setupRoutes(r, data, images)
+	setupAdminRoutes(r, data, keys, utils.GetEnv("ADMIN_TOKEN", ""))
 
-	if err := r.Run(":8080"); err != nil {
+	server := &http.Server{Addr: ":8080", Handler: r}
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		log.Printf("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), utils.GetEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down: %v", err)
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Failed to run server: %v", err)
 	}
+	// ListenAndServe returns as soon as Shutdown starts; the requests in
+	// flight are done once Shutdown returns.
+	<-shutdown
+	<-keysDone
+	if err := keys.FlushUsage(time.Now()); err != nil {
+		log.Printf("Error writing API key usage: %v", err)
+	}
 }
 
-func setupRoutes(r *gin.Engine, characters []models.Character, attributes []models.Attribute, weapons map[string][]models.Weapon, echoes []models.Echo, sonatas []models.Sonata, stats []models.Stat, substats []models.Substat, codes []models.Code) {
-	r.GET("/", handlers.HomeHandler(characters, attributes, weapons, echoes))
+func setupRoutes(r *gin.Engine, s store.Store, images *assets.Images) {
+	r.GET("/", handlers.HomeHandler(s))
 	
 	r.NoRoute(func(c *gin.Context) {handlers.NotFoundHandler(c, "Route not found")})
 
-	r.GET("/codes", handlers.CodesHandler(codes))
+	r.GET("/codes", handlers.CodesHandler(s))
+	feedAuthority := utils.GetEnv("FEED_AUTHORITY", "api.resonance.rest")
+	r.GET("/codes/feed.xml", handlers.CodesAtomHandler(s, feedAuthority))
+	r.GET("/codes/feed.json", handlers.CodesJSONFeedHandler(s, feedAuthority))
+	r.GET("/codes/:name", handlers.GetCodeHandler(s))
+	r.GET("/search", handlers.SearchHandler(s))
 
 
 	// Character routes
-	r.GET("/characters", handlers.ListCharactersHandler(characters))
-	r.GET("/characters/:name", handlers.GetCharacterHandler(characters))
-	r.GET("/characters/:name/emojis", handlers.CharacterEmojisHandler)
-	r.GET("/characters/:name/emojis/:index", handlers.CharacterEmojiHandler)
-	r.GET("/characters/:name/:imagetype", handlers.CharacterImageHandler)
+	r.GET("/characters", handlers.ListCharactersHandler(s))
+	r.GET("/characters/:name", handlers.GetCharacterHandler(s))
+	r.GET("/characters/:name/emojis", handlers.CharacterEmojisHandler(images))
+	r.GET("/characters/:name/emojis/:index", handlers.CharacterEmojiHandler(images))
+	r.GET("/characters/:name/stats", handlers.CharacterStatsHandler(s))
+	r.GET("/characters/:name/skills", handlers.CharacterSkillsHandler(s))
+	r.GET("/characters/:name/chains", handlers.CharacterChainsHandler(s))
+	r.GET("/characters/:name/:imagetype", handlers.CharacterImageHandler(images))
 
 	// Attribute routes
-	r.GET("/attributes", handlers.ListAttributesHandler(attributes))
-	r.GET("/attributes/:name", handlers.GetAttributeHandler(attributes))
-	r.GET("/attributes/:name/icon", handlers.AttributeIconHandler)
+	r.GET("/attributes", handlers.ListAttributesHandler(s))
+	r.GET("/attributes/:name", handlers.GetAttributeHandler(s))
+	r.GET("/attributes/:name/icon", handlers.AttributeIconHandler(images))
 
 	// Weapon routes
-	r.GET("/weapons", handlers.ListWeaponTypesHandler(weapons))
-	r.GET("/weapons/:type", handlers.ListWeaponsHandler(weapons))
-	r.GET("/weapons/:type/:name", handlers.GetWeaponHandler(weapons))
-	r.GET("/weapons/:type/:name/icon", handlers.WeaponIconHandler)
+	r.GET("/weapons", handlers.ListWeaponTypesHandler(s))
+	r.GET("/weapons/:type", handlers.ListWeaponsHandler(s))
+	r.GET("/weapons/:type/:name", handlers.GetWeaponHandler(s))
+	r.GET("/weapons/:type/:name/icon", handlers.WeaponIconHandler(images))
+	r.GET("/weapons/:type/:name/refinements", handlers.WeaponRefinementsHandler(s))
+	r.GET("/weapons/:type/:name/stats", handlers.WeaponStatsHandler(s))
 
 	// Echo routes
-	r.GET("/echoes", handlers.ListEchoesHandler(echoes))
-	r.GET("/echoes/:name", handlers.GetEchoHandler(echoes))
+	r.GET("/echoes", handlers.ListEchoesHandler(s))
+	r.GET("/echoes/:name", handlers.GetEchoHandler(s))
+	r.POST("/echoes/simulate", handlers.SimulateEchoHandler(s))
+	r.POST("/echoes/score", handlers.ScoreEchoHandler(s))
+	r.POST("/echoes/optimize", handlers.OptimizeEchoesHandler(s))
 
 	// Sonata routes
-	r.GET("/echoes/sonatas", handlers.ListSonatasHandler(sonatas))
-	r.GET("/echoes/sonatas/:name", handlers.GetSonataHandler(sonatas))
+	r.GET("/echoes/sonatas", handlers.ListSonatasHandler(s))
+	r.GET("/echoes/sonatas/:name", handlers.GetSonataHandler(s))
+	r.GET("/echoes/sonatas/:name/echoes", handlers.SonataEchoesHandler(s))
 
 	// Stat routes
-	r.GET("/echoes/stats", handlers.ListStatsHandler(stats))
-	r.GET("/echoes/stats/:name", handlers.GetStatHandler(stats))
+	r.GET("/echoes/stats", handlers.ListStatsHandler(s))
+	r.GET("/echoes/stats/:name", handlers.GetStatHandler(s))
 
 	// Substat routes
-	r.GET("/echoes/substats", handlers.ListSubstatsHandler(substats))
-	r.GET("/echoes/substats/:name", handlers.GetSubstatHandler(substats))
+	r.GET("/echoes/substats", handlers.ListSubstatsHandler(s))
+	r.GET("/echoes/substats/:name", handlers.GetSubstatHandler(s))
 
+	// Material routes
+	r.GET("/materials", handlers.ListMaterialsHandler(s))
+	r.GET("/materials/:name", handlers.GetMaterialHandler(s))
+	r.POST("/planner", handlers.PlannerHandler(s))
+
+	// Build routes
+	r.POST("/builds/evaluate", handlers.EvaluateBuildHandler(s))
+
+	// Calculator routes
+	r.POST("/calc/damage", handlers.DamageHandler(s))
+
+
+}
 
-}
\ No newline at end of file
+func setupAdminRoutes(r *gin.Engine, reloader *store.Reloader, keys *apikeys.Keyring, token string) {
+	if token == "" {
+		return
+	}
+
+	admin := r.Group("/admin", utils.AdminTokenMiddleware(token))
+	admin.GET("/reload", handlers.ReloadStatusHandler(reloader))
+	admin.POST("/reload", handlers.ReloadHandler(reloader))
+
+	admin.GET("/keys", handlers.ListKeysHandler(keys))
+	admin.POST("/keys", handlers.CreateKeyHandler(keys))
+	admin.GET("/keys/:id", handlers.GetKeyHandler(keys))
+	admin.DELETE("/keys/:id", handlers.RevokeKeyHandler(keys))
+}
+
+// newImages configures the image routes. ASSET_CACHE_SIZE is in megabytes;
+// 0 turns off the disk cache, which keeps the proxy's images and the resized
+// ones.
+func newImages() (*assets.Images, error) {
+	var cache *assets.DiskCache
+	if size := utils.GetEnvInt("ASSET_CACHE_SIZE", 256); size > 0 {
+		var err error
+		cache, err = assets.OpenDiskCache(utils.GetEnv("ASSET_CACHE_DIR", "cache/assets"), int64(size)<<20)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	source, err := newAssetSource(cache)
+	if err != nil {
+		return nil, err
+	}
+	images := assets.NewImages(source, cache)
+	images.MaxDimension = max(utils.GetEnvInt("ASSET_MAX_DIMENSION", images.MaxDimension), 1)
+	return images, nil
+}
+
+// newAssetSource configures where the images are served from: ASSET_BACKEND
+// is "cdn" for the CDN proxy, "dir" for the directory ASSET_DIR or "embed"
+// for the images built into the binary.
+func newAssetSource(cache *assets.DiskCache) (assets.Source, error) {
+	maxAge := utils.GetEnvDuration("ASSET_MAX_AGE", time.Hour)
+
+	switch backend := utils.GetEnv("ASSET_BACKEND", "cdn"); backend {
+	case "cdn":
+		proxy := assets.NewProxy(utils.GetEnv("ASSET_CDN_URL", "http://cdn.resonance.rest/"), cache,
+			utils.GetEnvDuration("ASSET_TIMEOUT", 10*time.Second))
+		proxy.MaxAge = maxAge
+		proxy.StaleFor = utils.GetEnvDuration("ASSET_STALE", proxy.StaleFor)
+		return proxy, nil
+
+	case "dir", "embed":
+		var fsys fs.FS
+		if backend == "dir" {
+			dir := utils.GetEnv("ASSET_DIR", "assets/files")
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("ASSET_DIR %s is not a directory", dir)
+			}
+			fsys = os.DirFS(dir)
+		} else {
+			var err error
+			if fsys, err = assets.Embedded(); err != nil {
+				return nil, err
+			}
+		}
+
+		source := assets.NewFS(fsys)
+		source.CacheControl = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+		return source, nil
+
+	default:
+		return nil, fmt.Errorf("unknown ASSET_BACKEND %q, expected cdn, dir or embed", backend)
+	}
+}
+
+// defaultRouteCosts charges the calculators that search through many
+// combinations more than a plain lookup.
+const defaultRouteCosts = "POST /echoes/optimize=10,POST /echoes/simulate=5"
+
+// newRateLimiter configures the rate limiter from the environment. Its limit
+// applies to requests without an API key, which are not limited when
+// RATE_LIMIT is 0.
+func newRateLimiter() *ratelimit.Limiter {
+	requests := utils.GetEnvInt("RATE_LIMIT", 200)
+	limiter := ratelimit.New(ratelimit.Limit{
+		Requests: requests,
+		Window:   utils.GetEnvDuration("RATE_LIMIT_WINDOW", time.Minute),
+		Burst:    max(utils.GetEnvInt("RATE_LIMIT_BURST", requests), 1),
+	})
+	costs, err := ratelimit.ParseCosts(utils.GetEnv("RATE_LIMIT_COSTS", defaultRouteCosts))
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_COSTS: %v", err)
+	}
+	limiter.Costs = costs
+	return limiter
+}
+
+func reloadOnSignal(reloader *store.Reloader) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if err := reloader.Reload(); err != nil {
+			log.Printf("Error reloading data, keeping previous snapshot: %v", err)
+			continue
+		}
+		log.Printf("Reloaded data")
+	}
+}
+
+func runValidate(dataDir string) int {
+	data, err := store.LoadJSON(dataDir)
+	if err != nil {
+		log.Printf("Error loading data: %v", err)
+		return 1
+	}
+
+	issues := validation.Validate(data, dataDir)
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if len(issues) > 0 {
+		fmt.Printf("%d issues found\n", len(issues))
+		return 1
+	}
+
+	fmt.Println("No issues found")
+	return 0
+}
+
+// runKeys manages the API keys in keysFile. A running server picks up the
+// changes without a restart.
+func runKeys(keysFile string, args []string) int {
+	usage := func() int {
+		fmt.Println("usage: keys list | keys create <name> [tier] | keys revoke <id>")
+		return 2
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+
+	keys, err := apikeys.Open(keysFile)
+	if err != nil {
+		log.Printf("Error loading API keys: %v", err)
+		return 1
+	}
+
+	now := time.Now()
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tTIER\tCREATED\tTODAY\tREVOKED")
+		for _, key := range keys.Keys() {
+			revoked := "-"
+			if key.Revoked != nil {
+				revoked = key.Revoked.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", key.ID, key.Name, key.Tier,
+				key.Created.Format(time.RFC3339), keys.Used(key.ID, now), revoked)
+		}
+		w.Flush()
+
+	case args[0] == "create" && (len(args) == 2 || len(args) == 3):
+		tier := "free"
+		if len(args) == 3 {
+			tier = args[2]
+		}
+		token, key, err := keys.Create(args[1], tier, now)
+		if err != nil {
+			log.Printf("Error creating API key: %v", err)
+			return 1
+		}
+		fmt.Printf("Created key %s (%s) in tier %s\n", key.ID, key.Name, key.Tier)
+		fmt.Printf("Token, shown only once: %s\n", token)
+
+	case args[0] == "revoke" && len(args) == 2:
+		key, err := keys.Revoke(args[1], now)
+		if err != nil {
+			log.Printf("Error revoking API key %s: %v", args[1], err)
+			return 1
+		}
+		fmt.Printf("Revoked key %s (%s)\n", key.ID, key.Name)
+
+	default:
+		return usage()
+	}
+	return 0
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Character struct {
 	Name       string `json:"name"`
 	Quote      string `json:"quote,omitempty"`
@@ -9,4 +14,165 @@ type Character struct {
 	Class      string `json:"class,omitempty"`
 	Birthplace string `json:"birthplace,omitempty"`
 	Birthday   string `json:"birthday,omitempty"`
-}
\ No newline at end of file
+
+	Stats     []StatPoint      `json:"stats,omitempty"`
+	Ascension []AscensionPhase `json:"ascension,omitempty"`
+	Skills    []CharacterSkill `json:"skills,omitempty"`
+	Forte     []UpgradeCost    `json:"forte,omitempty"`
+	Chains    []ResonanceChain `json:"chains,omitempty"`
+
+	// Source is the file the character was loaded from.
+	Source string `json:"-"`
+}
+
+// Level and progression limits of a character.
+const (
+	MaxCharacterLevel = 90
+	MaxAscension      = 6
+	MaxSkillLevel     = 10
+	MaxChainSequence  = 6
+)
+
+// StatPoint holds a character's base stats at one level and ascension phase.
+// Stats between two points of the same phase are interpolated.
+type StatPoint struct {
+	Level     int     `json:"level"`
+	Ascension int     `json:"ascension"`
+	HP        float64 `json:"hp"`
+	ATK       float64 `json:"atk"`
+	DEF       float64 `json:"def"`
+}
+
+// AscensionPhase is the cost of raising a character's level cap to MaxLevel.
+type AscensionPhase struct {
+	Phase     int            `json:"phase"`
+	MaxLevel  int            `json:"maxLevel"`
+	Credits   int            `json:"credits,omitempty"`
+	Materials []MaterialCost `json:"materials,omitempty"`
+}
+
+// MaterialCost is an amount of a material spent on an upgrade.
+type MaterialCost struct {
+	Name   string `json:"name"`
+	Amount int    `json:"amount"`
+}
+
+// CharacterSkill is one skill of a character's forte. Bonuses are the stat
+// nodes of the forte tree unlocked alongside it.
+type CharacterSkill struct {
+	Type        string            `json:"type"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Multipliers []SkillMultiplier `json:"multipliers,omitempty"`
+	Bonuses     []StatBonus       `json:"bonuses,omitempty"`
+}
+
+// SkillMultiplier lists the value of one skill attribute at each skill level,
+// starting at level 1. Scaling names the stat a damage multiplier applies to
+// and Unit the unit of its values, usually "%".
+type SkillMultiplier struct {
+	Name    string    `json:"name"`
+	Scaling string    `json:"scaling,omitempty"`
+	Unit    string    `json:"unit,omitempty"`
+	Hits    int       `json:"hits,omitempty"`
+	Values  []float64 `json:"values"`
+
+	// Level and Value are only set when a skill level was requested.
+	Level int      `json:"level,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+// StatBonus is a flat bonus to a stat.
+type StatBonus struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// ResonanceChain is one node of a character's resonance chain.
+type ResonanceChain struct {
+	Sequence    int    `json:"sequence"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Check rejects stats, ascension phases, skills, forte costs and chains that
+// are shaped wrongly. The loader refuses characters that fail it, as the
+// calculators depend on those fields being consistent.
+func (c Character) Check() error {
+	seen := make(map[[2]int]bool)
+	for i, point := range c.Stats {
+		if point.Level < 1 || point.Level > MaxCharacterLevel {
+			return fmt.Errorf("stats[%d]: level %d is outside 1-%d", i, point.Level, MaxCharacterLevel)
+		}
+		if point.Ascension < 0 || point.Ascension > MaxAscension {
+			return fmt.Errorf("stats[%d]: ascension %d is outside 0-%d", i, point.Ascension, MaxAscension)
+		}
+		if point.HP <= 0 || point.ATK <= 0 || point.DEF <= 0 {
+			return fmt.Errorf("stats[%d]: hp, atk and def must be positive", i)
+		}
+		key := [2]int{point.Level, point.Ascension}
+		if seen[key] {
+			return fmt.Errorf("stats[%d]: level %d at ascension %d is listed twice", i, point.Level, point.Ascension)
+		}
+		seen[key] = true
+	}
+
+	maxLevel := 0
+	for i, phase := range c.Ascension {
+		if phase.Phase != i+1 {
+			return fmt.Errorf("ascension[%d]: expected phase %d, got %d", i, i+1, phase.Phase)
+		}
+		if phase.MaxLevel <= maxLevel || phase.MaxLevel > MaxCharacterLevel {
+			return fmt.Errorf("ascension[%d]: max level %d must be above %d and at most %d", i, phase.MaxLevel, maxLevel, MaxCharacterLevel)
+		}
+		maxLevel = phase.MaxLevel
+		for j, material := range phase.Materials {
+			if material.Name == "" || material.Amount <= 0 {
+				return fmt.Errorf("ascension[%d].materials[%d]: needs a name and a positive amount", i, j)
+			}
+		}
+	}
+	if len(c.Ascension) > MaxAscension {
+		return fmt.Errorf("ascension: at most %d phases", MaxAscension)
+	}
+
+	for i, skill := range c.Skills {
+		if skill.Type == "" || skill.Name == "" {
+			return fmt.Errorf("skills[%d]: needs a type and a name", i)
+		}
+		for j, multiplier := range skill.Multipliers {
+			if multiplier.Name == "" {
+				return fmt.Errorf("skills[%d].multipliers[%d]: needs a name", i, j)
+			}
+			if len(multiplier.Values) == 0 || len(multiplier.Values) > MaxSkillLevel {
+				return fmt.Errorf("skills[%d].multipliers[%d]: needs 1-%d values, got %d", i, j, MaxSkillLevel, len(multiplier.Values))
+			}
+		}
+	}
+
+	for i, cost := range c.Forte {
+		if cost.Level < 2 || cost.Level > MaxSkillLevel {
+			return fmt.Errorf("forte[%d]: level %d is outside 2-%d", i, cost.Level, MaxSkillLevel)
+		}
+		for j, material := range cost.Materials {
+			if material.Name == "" || material.Amount <= 0 {
+				return fmt.Errorf("forte[%d].materials[%d]: needs a name and a positive amount", i, j)
+			}
+		}
+	}
+
+	sequences := make(map[int]bool)
+	for i, chain := range c.Chains {
+		if chain.Sequence < 1 || chain.Sequence > MaxChainSequence {
+			return fmt.Errorf("chains[%d]: sequence %d is outside 1-%d", i, chain.Sequence, MaxChainSequence)
+		}
+		if sequences[chain.Sequence] {
+			return fmt.Errorf("chains[%d]: sequence %d is listed twice", i, chain.Sequence)
+		}
+		sequences[chain.Sequence] = true
+		if strings.TrimSpace(chain.Name) == "" {
+			return fmt.Errorf("chains[%d]: needs a name", i)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,176 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Code struct {
-	Name          string        `json:"name,omitempty"`
-	Reward        string        `json:"reward,omitempty"`
-}
\ No newline at end of file
+	Name    string  `json:"name,omitempty"`
+	Rewards Rewards `json:"rewards,omitempty"`
+	Region  string  `json:"region,omitempty"`
+	Added   *Date   `json:"added,omitempty"`
+	Expires *Date   `json:"expires,omitempty"`
+
+	// Status is only set in responses, see Code.StatusAt.
+	Status string `json:"status,omitempty"`
+}
+
+// Code statuses.
+const (
+	CodeActive  = "active"
+	CodeExpired = "expired"
+)
+
+// StatusAt returns CodeExpired once a code's expiry has passed at now and
+// CodeActive otherwise, including for codes without a known expiry.
+func (c Code) StatusAt(now time.Time) string {
+	if c.Expires != nil && !now.Before(c.Expires.End()) {
+		return CodeExpired
+	}
+	return CodeActive
+}
+
+// UnmarshalJSON also accepts the older format, where the rewards were a free
+// text "reward" string.
+func (c *Code) UnmarshalJSON(data []byte) error {
+	type code Code
+	var raw struct {
+		code
+		Reward string `json:"reward"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = Code(raw.code)
+	if len(c.Rewards) == 0 && raw.Reward != "" {
+		rewards, err := ParseRewards(raw.Reward)
+		if err != nil {
+			return err
+		}
+		c.Rewards = rewards
+	}
+	return nil
+}
+
+// MarshalJSON also writes the rewards as text under "reward", the format
+// codes were served in before their rewards were itemised, so older clients
+// keep working.
+func (c Code) MarshalJSON() ([]byte, error) {
+	type code Code
+	return json.Marshal(struct {
+		code
+		Reward string `json:"reward,omitempty"`
+	}{code(c), c.Rewards.String()})
+}
+
+// Reward is an item and the quantity of it a code gives.
+type Reward struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// Rewards lists the items a code gives. In the data files it is written as a
+// list of items or as text such as "2 Premium resonance potions, 10k Shell
+// credits".
+type Rewards []Reward
+
+func (r *Rewards) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		rewards, err := ParseRewards(text)
+		*r = rewards
+		return err
+	}
+
+	var items []Reward
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("rewards must be a list of items or a text: %v", err)
+	}
+	*r = items
+	return nil
+}
+
+// String writes the rewards as comma separated text, the reverse of
+// ParseRewards.
+func (r Rewards) String() string {
+	parts := make([]string, len(r))
+	for i, reward := range r {
+		parts[i] = fmt.Sprintf("%d %s", reward.Quantity, reward.Item)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseRewards reads comma separated rewards such as "10k Shell credits",
+// each a quantity, optionally with a k suffix for thousands, and an item.
+func ParseRewards(text string) (Rewards, error) {
+	var rewards Rewards
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		quantity, item, ok := strings.Cut(part, " ")
+		if !ok {
+			return nil, fmt.Errorf("reward %q has no quantity", part)
+		}
+		multiplier := 1
+		if strings.HasSuffix(strings.ToLower(quantity), "k") {
+			multiplier = 1000
+			quantity = quantity[:len(quantity)-1]
+		}
+		n, err := strconv.ParseFloat(quantity, 64)
+		if err != nil {
+			return nil, fmt.Errorf("reward %q has no quantity", part)
+		}
+		rewards = append(rewards, Reward{Item: strings.TrimSpace(item), Quantity: int(n * float64(multiplier))})
+	}
+	return rewards, nil
+}
+
+// Date is a point in time written as a date, "2024-05-22", or a full
+// RFC 3339 timestamp. Plain dates are in UTC.
+type Date struct {
+	time.Time
+
+	// DateOnly is set when the date was written without a time.
+	DateOnly bool
+}
+
+const dateLayout = "2006-01-02"
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("date must be a string: %v", err)
+	}
+	if t, err := time.Parse(dateLayout, text); err == nil {
+		*d = Date{Time: t, DateOnly: true}
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return fmt.Errorf("date %q is neither YYYY-MM-DD nor RFC 3339", text)
+	}
+	*d = Date{Time: t}
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.DateOnly {
+		return json.Marshal(d.Format(dateLayout))
+	}
+	return json.Marshal(d.Format(time.RFC3339))
+}
+
+// End returns the moment a date runs out: the end of the day for plain dates
+// and the time itself otherwise.
+func (d Date) End() time.Time {
+	if d.DateOnly {
+		return d.AddDate(0, 0, 1)
+	}
+	return d.Time
+}
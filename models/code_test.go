@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCodeJSON(t *testing.T) {
+	var code Code
+	if err := json.Unmarshal([]byte(`{"name": "WUTHERINGGIFT", "reward": "2 Premium resonance potions, 10k Shell credits"}`), &code); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	want := Rewards{{Item: "Premium resonance potions", Quantity: 2}, {Item: "Shell credits", Quantity: 10000}}
+	if len(code.Rewards) != len(want) || code.Rewards[0] != want[0] || code.Rewards[1] != want[1] {
+		t.Errorf("Unmarshal() rewards = %+v, want %+v", code.Rewards, want)
+	}
+
+	body, err := json.Marshal(code)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("Unmarshal(%s) = %v", body, err)
+	}
+	if out["reward"] != "2 Premium resonance potions, 10000 Shell credits" {
+		t.Errorf("Marshal() reward = %v, want the rewards as text", out["reward"])
+	}
+	if _, ok := out["rewards"].([]any); !ok {
+		t.Errorf("Marshal() = %s, want the rewards itemised too", body)
+	}
+}
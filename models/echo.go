@@ -1,11 +1,90 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
 type Echo struct {
-	Name          string        `json:"name,omitempty"`
-	Cost          int           `json:"cost,omitempty"`
-	SonataEffects []string      `json:"sonataEffects,omitempty"`
-	Outline       string        `json:"outline,omitempty"`
-	Description   string        `json:"description,omitempty"`
-	Ranks         []interface{} `json:"ranks,omitempty"`
-	Cooldown      string        `json:"cooldown,omitempty"`
-}
\ No newline at end of file
+	Name          string    `json:"name,omitempty"`
+	Cost          int       `json:"cost,omitempty"`
+	SonataEffects []string  `json:"sonataEffects,omitempty"`
+	Outline       string    `json:"outline,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	Ranks         EchoRanks `json:"ranks,omitempty"`
+	Cooldown      string    `json:"cooldown,omitempty"`
+
+	// Rank and Rendered are only set when a rank was requested, Rendered
+	// being Description with that rank's values filled in.
+	Rank     int    `json:"rank,omitempty"`
+	Rendered string `json:"rendered,omitempty"`
+}
+
+// FirstEchoRank is the number of the lowest echo rank with skill values.
+const FirstEchoRank = 2
+
+// EchoRank holds the skill values of an echo at one rank, Values[n] being the
+// value of the {n} placeholder of its description.
+type EchoRank struct {
+	Rank        int       `json:"rank"`
+	Values      []float64 `json:"values"`
+	Description string    `json:"description,omitempty"`
+}
+
+// EchoRanks lists an echo's ranks in ascending order. In the data files it is
+// written either as a flat list holding a single parameter's value per rank,
+// numbered from FirstEchoRank, or as one object per parameter mapping rank
+// numbers to values. Both decode to the same rank by rank structure.
+type EchoRanks []EchoRank
+
+func (r *EchoRanks) UnmarshalJSON(data []byte) error {
+	var flat []float64
+	if err := json.Unmarshal(data, &flat); err == nil {
+		*r = make(EchoRanks, len(flat))
+		for i, value := range flat {
+			(*r)[i] = EchoRank{Rank: FirstEchoRank + i, Values: []float64{value}}
+		}
+		return nil
+	}
+
+	var ranks []EchoRank
+	if err := json.Unmarshal(data, &ranks); err == nil && (len(ranks) == 0 || ranks[0].Rank != 0) {
+		*r = ranks
+		return nil
+	}
+
+	var params []map[string]float64
+	if err := json.Unmarshal(data, &params); err != nil {
+		return fmt.Errorf("echo ranks must be a list of values or of rank objects: %v", err)
+	}
+
+	var numbers []int
+	for _, param := range params {
+		for key := range param {
+			n, err := strconv.Atoi(key)
+			if err != nil {
+				return fmt.Errorf("echo rank %q is not a number", key)
+			}
+			if !slices.Contains(numbers, n) {
+				numbers = append(numbers, n)
+			}
+		}
+	}
+	slices.Sort(numbers)
+
+	*r = make(EchoRanks, len(numbers))
+	for i, n := range numbers {
+		rank := EchoRank{Rank: n, Values: make([]float64, len(params))}
+		for j, param := range params {
+			value, ok := param[strconv.Itoa(n)]
+			if !ok {
+				return fmt.Errorf("echo rank %d has no value for parameter %d", n, j)
+			}
+			rank.Values[j] = value
+		}
+		(*r)[i] = rank
+	}
+	return nil
+}
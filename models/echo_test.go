@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestEchoRanksJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want EchoRanks
+		err  bool
+	}{
+		{`[20.7, 23.4]`, EchoRanks{{Rank: 2, Values: []float64{20.7}}, {Rank: 3, Values: []float64{23.4}}}, false},
+		{`[{"rank": 2, "values": [1, 2]}, {"rank": 3, "values": [3, 4]}]`, EchoRanks{{Rank: 2, Values: []float64{1, 2}}, {Rank: 3, Values: []float64{3, 4}}}, false},
+		{`[{"2": 34.5, "3": 39}, {"3": 78, "2": 69}]`, EchoRanks{{Rank: 2, Values: []float64{34.5, 69}}, {Rank: 3, Values: []float64{39, 78}}}, false},
+		{`[{"10": 2, "9": 1}]`, EchoRanks{{Rank: 9, Values: []float64{1}}, {Rank: 10, Values: []float64{2}}}, false},
+		{`[]`, EchoRanks{}, false},
+		{`[{"2": 34.5, "3": 39}, {"2": 69}]`, nil, true},
+		{`[{"two": 34.5}]`, nil, true},
+		{`"34.5"`, nil, true},
+	}
+	for _, tt := range tests {
+		var got EchoRanks
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if (err != nil) != tt.err {
+			t.Errorf("Unmarshal(%s) error = %v, want error %v", tt.data, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.data, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Rank != tt.want[i].Rank || !slices.Equal(got[i].Values, tt.want[i].Values) {
+				t.Errorf("Unmarshal(%s)[%d] = %+v, want %+v", tt.data, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
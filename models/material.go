@@ -0,0 +1,33 @@
+package models
+
+type Material struct {
+	Name        string   `json:"name,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Rarity      int      `json:"rarity,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Sources     []string `json:"sources,omitempty"`
+}
+
+// Costs holds the EXP and shell credits needed to level characters and
+// weapons up.
+type Costs struct {
+	Character LevelCosts `json:"character"`
+	Weapon    LevelCosts `json:"weapon"`
+}
+
+// LevelCosts lists the EXP and shell credits spent on each level up, EXP[0]
+// and Credits[0] being the cost of going from level 1 to 2.
+type LevelCosts struct {
+	EXP     []int `json:"exp"`
+	Credits []int `json:"credits"`
+}
+
+// ShellCredit is the name of the currency every upgrade costs.
+const ShellCredit = "Shell Credit"
+
+// UpgradeCost is the cost of raising a skill to Level.
+type UpgradeCost struct {
+	Level     int            `json:"level"`
+	Credits   int            `json:"credits,omitempty"`
+	Materials []MaterialCost `json:"materials,omitempty"`
+}
@@ -1,26 +1,119 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
 type Weapon struct {
 	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
 	Type        string `json:"type,omitempty"`
 	Rarity      int    `json:"rarity,omitempty"`
 	Stats       struct {
-		Attack  int `json:"atk,omitempty"`
-		Substat struct {
-			SubName  string `json:"name,omitempty"`
-			SubValue string `json:"value,omitempty"`
-		} `json:"substat,omitempty"`
+		Attack  int               `json:"atk,omitempty"`
+		Substat WeaponSubstat     `json:"substat,omitempty"`
+		Levels  []WeaponStatPoint `json:"levels,omitempty"`
 	} `json:"stats,omitempty"`
 	Skill struct {
-		Name        string `json:"name,omitempty"`
-		Description string `json:"description,omitempty"`
-		Ranks       []struct {
-			Zero  string `json:"0,omitempty"`
-			One   string `json:"1,omitempty"`
-			Three string `json:"3,omitempty"`
-			Four  string `json:"4,omitempty"`
-			Five  string `json:"5,omitempty"`
-		} `json:"ranks,omitempty"`
+		Name        string      `json:"name,omitempty"`
+		Description string      `json:"description,omitempty"`
+		Ranks       []SkillRank `json:"ranks,omitempty"`
+
+		// Refinement and Rendered are only set when a refinement level was
+		// requested, Rendered being Description with its values filled in.
+		Refinement int    `json:"refinement,omitempty"`
+		Rendered   string `json:"rendered,omitempty"`
 	} `json:"skill,omitempty"`
-}
\ No newline at end of file
+	Ascension []AscensionPhase `json:"ascension,omitempty"`
+}
+
+// SkillRank holds the value of one skill parameter at each refinement level,
+// starting at refinement 1. It decodes from a plain array or from an object
+// keyed by number, whose values are taken in key order whatever the keys are.
+type SkillRank []string
+
+func (r *SkillRank) UnmarshalJSON(data []byte) error {
+	var values []string
+	if err := json.Unmarshal(data, &values); err == nil {
+		*r = values
+		return nil
+	}
+
+	var keyed map[string]string
+	if err := json.Unmarshal(data, &keyed); err != nil {
+		return fmt.Errorf("skill rank must be an array or an object of values: %v", err)
+	}
+
+	keys := make([]int, 0, len(keyed))
+	for key := range keyed {
+		n, err := strconv.Atoi(key)
+		if err != nil {
+			return fmt.Errorf("skill rank key %q is not a number", key)
+		}
+		keys = append(keys, n)
+	}
+	slices.Sort(keys)
+
+	*r = make(SkillRank, len(keys))
+	for i, key := range keys {
+		(*r)[i] = keyed[strconv.Itoa(key)]
+	}
+	return nil
+}
+
+// WeaponSubstat is a weapon's secondary stat at level 1. Unit is "%" for
+// percentages and empty for flat values. In the data files the value is
+// written either as a number or as a string such as "8.1%".
+type WeaponSubstat struct {
+	Name  string  `json:"name,omitempty"`
+	Value float64 `json:"value,omitempty"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+func (s *WeaponSubstat) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+		Unit  string          `json:"unit"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = WeaponSubstat{Name: raw.Name, Unit: raw.Unit}
+	if len(raw.Value) == 0 || string(raw.Value) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw.Value, &s.Value); err == nil {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(raw.Value, &text); err != nil {
+		return fmt.Errorf("substat value must be a number or a string: %v", err)
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasSuffix(text, "%") {
+		s.Unit = "%"
+		text = strings.TrimSpace(strings.TrimSuffix(text, "%"))
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("substat value %q is not a number", text)
+	}
+	s.Value = value
+	return nil
+}
+
+// WeaponStatPoint holds a weapon's base ATK and substat value at one level
+// and ascension phase. Values between two points of the same phase are
+// interpolated; the level 1 values double as the first point.
+type WeaponStatPoint struct {
+	Level     int     `json:"level"`
+	Ascension int     `json:"ascension"`
+	ATK       float64 `json:"atk"`
+	Substat   float64 `json:"substat"`
+}
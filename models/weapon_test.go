@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSkillRankJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want SkillRank
+		err  bool
+	}{
+		{`["10%", "12.5%", "15%", "17.5%", "20%"]`, SkillRank{"10%", "12.5%", "15%", "17.5%", "20%"}, false},
+		{`{"0": "10%", "1": "12.5%", "2": "15%"}`, SkillRank{"10%", "12.5%", "15%"}, false},
+		// Keys sort as numbers, and gaps are closed up.
+		{`{"10": "c", "2": "b", "1": "a"}`, SkillRank{"a", "b", "c"}, false},
+		{`{"0": "10%", "1": "5%", "3": "10%", "4": "5"}`, SkillRank{"10%", "5%", "10%", "5"}, false},
+		{`{"first": "10%"}`, nil, true},
+		{`"10%"`, nil, true},
+		{`[1, 2]`, nil, true},
+	}
+	for _, tt := range tests {
+		var got SkillRank
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if (err != nil) != tt.err {
+			t.Errorf("Unmarshal(%s) error = %v, want error %v", tt.data, err, tt.err)
+			continue
+		}
+		if err == nil && !slices.Equal(got, tt.want) {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
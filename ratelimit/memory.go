@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+const shardCount = 32
+
+// Memory is a Backend keeping buckets in this process. It serves a single
+// replica and stands in for a shared backend in tests. Buckets are spread
+// over shards so concurrent clients rarely wait on the same lock.
+type Memory struct {
+	seed   maphash.Seed
+	shards [shardCount]shard
+}
+
+type shard struct {
+	mu      sync.Mutex
+	buckets map[string]entry
+}
+
+// entry is a bucket and the limit it was last taken from, which decides when
+// it is idle.
+type entry struct {
+	bucket Bucket
+	limit  Limit
+}
+
+func NewMemory() *Memory {
+	m := &Memory{seed: maphash.MakeSeed()}
+	for i := range m.shards {
+		m.shards[i].buckets = make(map[string]entry)
+	}
+	return m
+}
+
+func (m *Memory) shard(key string) *shard {
+	return &m.shards[maphash.String(m.seed, key)%shardCount]
+}
+
+func (m *Memory) Take(_ context.Context, key string, limit Limit, cost float64, now time.Time) (Result, error) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, result := Take(s.buckets[key].bucket, limit, cost, now)
+	s.buckets[key] = entry{bucket: bucket, limit: limit}
+	return result, nil
+}
+
+// Evict forgets the buckets that have refilled completely.
+func (m *Memory) Evict(_ context.Context, now time.Time) (int, error) {
+	evicted := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		for key, e := range s.buckets {
+			if Idle(e.bucket, e.limit, now) {
+				delete(s.buckets, key)
+				evicted++
+			}
+		}
+		s.mu.Unlock()
+	}
+	return evicted, nil
+}
+
+// Len returns the number of buckets held.
+func (m *Memory) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		n += len(s.buckets)
+		s.mu.Unlock()
+	}
+	return n
+}
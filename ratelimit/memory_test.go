@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryEvict(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	m.Take(ctx, "busy", limit, 10, start)
+	m.Take(ctx, "light", limit, 1, start)
+
+	if n, _ := m.Evict(ctx, start); n != 0 || m.Len() != 2 {
+		t.Errorf("Evict() right away = %d, left %d, want nothing evicted", n, m.Len())
+	}
+	if n, _ := m.Evict(ctx, start.Add(time.Second)); n != 1 || m.Len() != 1 {
+		t.Errorf("Evict() after 1s = %d, left %d, want the refilled bucket evicted", n, m.Len())
+	}
+
+	// The remaining bucket keeps its state until it is idle too.
+	if result, _ := m.Take(ctx, "busy", limit, 1, start.Add(time.Second)); result.Remaining != 0 {
+		t.Errorf("Take(busy) after 1s = %+v, want the bucket kept", result)
+	}
+	if n, _ := m.Evict(ctx, start.Add(time.Minute)); n != 1 || m.Len() != 0 {
+		t.Errorf("Evict() after a minute = %d, left %d, want everything evicted", n, m.Len())
+	}
+}
+
+func TestMemoryConcurrent(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprint(i % 4)
+			if result, _ := m.Take(ctx, key, limit, 1, start); result.Allowed {
+				mu.Lock()
+				allowed[key]++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for key, n := range allowed {
+		if n != limit.Burst {
+			t.Errorf("client %s was allowed %d requests at once, want %d", key, n, limit.Burst)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package ratelimit limits how fast each client may call the API with a token
+// bucket per client: a client may burst up to Limit.Burst requests, and its
+// bucket refills at Limit.Requests per Limit.Window. Buckets live in a
+// Backend so replicas can share them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is the rate a client may sustain and the burst it may spend at once.
+// A zero Limit does not limit anything.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+	Burst    int
+}
+
+// Unlimited reports whether l leaves clients unlimited.
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Window <= 0
+}
+
+// perSecond is the rate at which a bucket refills.
+func (l Limit) perSecond() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// Result is the outcome of taking tokens from a bucket.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the request could be allowed, when it
+	// was not.
+	RetryAfter time.Duration
+}
+
+// Bucket is the state of one client's bucket.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Take refills b up to now and takes cost tokens from it if it holds enough.
+// Backends call it to apply the same arithmetic wherever buckets are kept.
+func Take(b Bucket, limit Limit, cost float64, now time.Time) (Bucket, Result) {
+	burst := float64(limit.Burst)
+	rate := limit.perSecond()
+
+	if b.Updated.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*rate)
+	}
+	b.Updated = now
+
+	var result Result
+	if b.Tokens >= cost {
+		b.Tokens -= cost
+		result.Allowed = true
+	} else if cost > burst {
+		result.RetryAfter = limit.Window
+	} else {
+		result.RetryAfter = seconds((cost - b.Tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(b.Tokens))
+	result.Reset = seconds((burst - b.Tokens) / rate)
+	return b, result
+}
+
+// Idle reports whether b has refilled completely by now, so forgetting it
+// changes nothing.
+func Idle(b Bucket, limit Limit, now time.Time) bool {
+	return b.Tokens+now.Sub(b.Updated).Seconds()*limit.perSecond() >= float64(limit.Burst)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Backend keeps the buckets. Take must refill and take from a bucket
+// atomically, so that replicas sharing a backend never hand out the same
+// tokens twice.
+type Backend interface {
+	Take(ctx context.Context, key string, limit Limit, cost float64, now time.Time) (Result, error)
+}
+
+// Evicter is implemented by backends that need idle buckets removed
+// periodically. Shared backends usually expire keys on their own instead.
+type Evicter interface {
+	Evict(ctx context.Context, now time.Time) (int, error)
+}
+
+// Limiter applies a Limit to clients, charging each route its cost. Clients
+// may also be given a Limit of their own through AllowLimit.
+type Limiter struct {
+	Limit   Limit
+	Backend Backend
+
+	// Costs maps "METHOD /route/:pattern" to the number of tokens a request
+	// to that route takes. Routes not listed cost one token.
+	Costs map[string]float64
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a Limiter keeping its buckets in memory.
+func New(limit Limit) *Limiter {
+	return &Limiter{Limit: limit, Backend: NewMemory(), Costs: make(map[string]float64)}
+}
+
+func (l *Limiter) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+// Cost returns the tokens a request to route, written "METHOD /path", takes.
+func (l *Limiter) Cost(route string) float64 {
+	if cost, ok := l.Costs[route]; ok {
+		return cost
+	}
+	return 1
+}
+
+// Allow takes cost tokens from the bucket of key.
+func (l *Limiter) Allow(ctx context.Context, key string, cost float64) (Result, error) {
+	return l.AllowLimit(ctx, key, l.Limit, cost)
+}
+
+// AllowLimit takes cost tokens from the bucket of key, which holds limit.
+func (l *Limiter) AllowLimit(ctx context.Context, key string, limit Limit, cost float64) (Result, error) {
+	return l.Backend.Take(ctx, key, limit, cost, l.now())
+}
+
+// Run evicts idle buckets every interval until ctx is done, if the backend
+// needs it.
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	evicter, ok := l.Backend.(Evicter)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := evicter.Evict(ctx, l.now()); err != nil {
+				log.Printf("Rate limit eviction failed: %v", err)
+			}
+		}
+	}
+}
+
+// ParseCosts reads route costs written "METHOD /route=cost", comma separated,
+// such as "POST /echoes/optimize=10,POST /calc/damage=2". Costs must be
+// positive, so every route is limited.
+func ParseCosts(text string) (map[string]float64, error) {
+	costs := make(map[string]float64)
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(part, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath {
+			return nil, fmt.Errorf("route cost %q is not METHOD /route=cost", part)
+		}
+		cost, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || cost <= 0 {
+			return nil, fmt.Errorf("route cost %q is not a positive number", part)
+		}
+		costs[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = cost
+	}
+	return costs, nil
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, time.May, 22, 12, 0, 0, 0, time.UTC)
+
+// limit refills one token a second up to 10.
+var limit = Limit{Requests: 60, Window: time.Minute, Burst: 10}
+
+func TestTakeRefill(t *testing.T) {
+	b, result := Take(Bucket{}, limit, 10, start)
+	if !result.Allowed || result.Remaining != 0 || result.Reset != 10*time.Second {
+		t.Fatalf("Take(10) on a new bucket = %+v, want the whole burst allowed", result)
+	}
+
+	b, result = Take(b, limit, 1, start.Add(500*time.Millisecond))
+	if result.Allowed || result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Take(1) after 0.5s = %+v, want a retry in 0.5s", result)
+	}
+
+	b, result = Take(b, limit, 1, start.Add(3*time.Second))
+	if !result.Allowed || result.Remaining != 2 {
+		t.Errorf("Take(1) after 3s = %+v, want allowed with 2 left", result)
+	}
+
+	_, result = Take(b, limit, 1, start.Add(time.Hour))
+	if !result.Allowed || result.Remaining != limit.Burst-1 {
+		t.Errorf("Take(1) after an hour = %+v, want the bucket refilled to the burst only", result)
+	}
+}
+
+func TestTakeCostAboveBurst(t *testing.T) {
+	b, result := Take(Bucket{}, limit, 11, start)
+	if result.Allowed || result.RetryAfter != limit.Window || result.Remaining != limit.Burst {
+		t.Errorf("Take(11) = %+v, want refused without taking tokens, retrying after the window", result)
+	}
+	if b.Tokens != float64(limit.Burst) {
+		t.Errorf("Take(11) left %v tokens, want %d", b.Tokens, limit.Burst)
+	}
+}
+
+func TestIdle(t *testing.T) {
+	b, _ := Take(Bucket{}, limit, 4, start)
+	if Idle(b, limit, start.Add(3*time.Second)) {
+		t.Errorf("Idle() after 3s = true with 9 of 10 tokens")
+	}
+	if !Idle(b, limit, start.Add(4*time.Second)) {
+		t.Errorf("Idle() after 4s = false with the bucket refilled")
+	}
+}
+
+func TestParseCosts(t *testing.T) {
+	costs, err := ParseCosts("post /echoes/optimize=10, GET /search = 2")
+	if err != nil {
+		t.Fatalf("ParseCosts() = %v", err)
+	}
+	if costs["POST /echoes/optimize"] != 10 || costs["GET /search"] != 2 {
+		t.Errorf("ParseCosts() = %v", costs)
+	}
+	for _, text := range []string{"/search=2", "GET /search", "GET /search=-1", "GET /search=0"} {
+		if _, err := ParseCosts(text); err == nil {
+			t.Errorf("ParseCosts(%q) succeeded", text)
+		}
+	}
+}
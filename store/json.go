@@ -0,0 +1,74 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"api/utils"
+)
+
+// Locations of each dataset relative to the data directory.
+var (
+	CharactersDir  = "characters"
+	AttributesFile = "attributes.json"
+	WeaponsFile    = "weapons.json"
+	EchoesFile     = "echoes.json"
+	SonatasFile    = "sonatas.json"
+	StatsFile      = filepath.Join("echoes", "stats.json")
+	SubstatsFile   = filepath.Join("echoes", "substats.json")
+	CodesFile      = "codes.json"
+	MaterialsFile  = "materials.json"
+	CostsFile      = "costs.json"
+	AliasesFile    = "aliases.json"
+)
+
+// LoadJSON reads the game data from the JSON files under dir, using the same
+// layout as the data/ directory in this repository.
+func LoadJSON(dir string) (*Memory, error) {
+	var (
+		data Data
+		err  error
+	)
+
+	if data.Characters, err = utils.LoadCharacters(filepath.Join(dir, CharactersDir)); err != nil {
+		return nil, fmt.Errorf("error loading characters: %v", err)
+	}
+	if data.Attributes, err = utils.LoadAttributes(filepath.Join(dir, AttributesFile)); err != nil {
+		return nil, fmt.Errorf("error loading attributes: %v", err)
+	}
+	if data.Weapons, err = utils.LoadWeapons(filepath.Join(dir, WeaponsFile)); err != nil {
+		return nil, fmt.Errorf("error loading weapons: %v", err)
+	}
+	if data.Echoes, err = utils.LoadEchoes(filepath.Join(dir, EchoesFile)); err != nil {
+		return nil, fmt.Errorf("error loading echoes: %v", err)
+	}
+	if data.Sonatas, err = utils.LoadSonatas(filepath.Join(dir, SonatasFile)); err != nil {
+		return nil, fmt.Errorf("error loading sonatas: %v", err)
+	}
+	if data.Stats, err = utils.LoadStats(filepath.Join(dir, StatsFile)); err != nil {
+		return nil, fmt.Errorf("error loading stats: %v", err)
+	}
+	if data.Substats, err = utils.LoadSubstats(filepath.Join(dir, SubstatsFile)); err != nil {
+		return nil, fmt.Errorf("error loading substats: %v", err)
+	}
+	if data.Codes, err = utils.LoadCodes(filepath.Join(dir, CodesFile)); err != nil {
+		return nil, fmt.Errorf("error loading codes: %v", err)
+	}
+	if data.Materials, err = utils.LoadMaterials(filepath.Join(dir, MaterialsFile)); err != nil {
+		return nil, fmt.Errorf("error loading materials: %v", err)
+	}
+	if data.Costs, err = utils.LoadCosts(filepath.Join(dir, CostsFile)); err != nil {
+		return nil, fmt.Errorf("error loading costs: %v", err)
+	}
+
+	aliases, err := utils.LoadAliases(filepath.Join(dir, AliasesFile))
+	if err != nil {
+		return nil, fmt.Errorf("error loading aliases: %v", err)
+	}
+	data.Aliases = make(map[Kind]map[string]string, len(aliases))
+	for kind, names := range aliases {
+		data.Aliases[Kind(kind)] = names
+	}
+
+	return NewMemory(data), nil
+}
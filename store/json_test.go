@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+
+	"api/models"
+)
+
+// TestLoadJSONData loads the data shipped in the repository, so a malformed
+// file fails the build rather than the server's start.
+func TestLoadJSONData(t *testing.T) {
+	m, err := LoadJSON("../data")
+	if err != nil {
+		t.Fatalf("LoadJSON(../data) = %v", err)
+	}
+	if err := m.Check(); err != nil {
+		t.Fatalf("Check() = %v", err)
+	}
+
+	characters := m.Characters()
+	if len(characters) == 0 {
+		t.Fatal("no characters loaded")
+	}
+	for _, character := range characters {
+		if err := character.Check(); err != nil {
+			t.Errorf("%s: %v", character.Name, err)
+		}
+		if len(character.Stats) == 0 || len(character.Skills) == 0 {
+			t.Errorf("%s: has %d stat points and %d skills, want both", character.Name, len(character.Stats), len(character.Skills))
+		}
+		if len(character.Ascension) != models.MaxAscension {
+			t.Errorf("%s: has %d ascension phases, want %d", character.Name, len(character.Ascension), models.MaxAscension)
+		}
+	}
+}
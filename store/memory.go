@@ -0,0 +1,285 @@
+package store
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"api/models"
+)
+
+// Data is the raw game dataset a Memory store is built from.
+type Data struct {
+	Characters []models.Character
+	Attributes []models.Attribute
+	Weapons    map[string][]models.Weapon
+	Echoes     []models.Echo
+	Sonatas    []models.Sonata
+	Stats      []models.Stat
+	Substats   []models.Substat
+	Codes      []models.Code
+	Materials  []models.Material
+	Costs      models.Costs
+
+	// Aliases maps alternative names to the name of the entry they stand for,
+	// per kind.
+	Aliases map[Kind]map[string]string
+}
+
+// Memory is a Store backed by in-memory slices. It is used both for the
+// JSON files in data/ and for fixtures.
+type Memory struct {
+	data        Data
+	weaponTypes []string
+	search      *searchIndex
+
+	characters  nameIndex
+	attributes  nameIndex
+	weaponIndex nameIndex
+	weapons     map[string]nameIndex
+	weaponNames []candidate
+	echoes      nameIndex
+	sonatas     nameIndex
+	stats       nameIndex
+	substats    nameIndex
+	codes       nameIndex
+	materials   nameIndex
+}
+
+// NewMemory indexes data by slug and alias. When two entries share a slug the
+// first one wins.
+func NewMemory(data Data) *Memory {
+	m := &Memory{
+		data:       data,
+		characters: newNameIndex(data.Characters, func(c models.Character) string { return c.Name }, data.Aliases[KindCharacters]),
+		attributes: newNameIndex(data.Attributes, func(a models.Attribute) string { return a.Name }, data.Aliases[KindAttributes]),
+		weapons:    make(map[string]nameIndex, len(data.Weapons)),
+		echoes:     newNameIndex(data.Echoes, func(e models.Echo) string { return e.Name }, data.Aliases[KindEchoes]),
+		sonatas:    newNameIndex(data.Sonatas, func(s models.Sonata) string { return s.Name }, data.Aliases[KindSonatas]),
+		stats:      newNameIndex(data.Stats, func(s models.Stat) string { return s.Name }, data.Aliases[KindStats]),
+		substats:   newNameIndex(data.Substats, func(s models.Substat) string { return s.Name }, data.Aliases[KindSubstats]),
+		codes:      newNameIndex(data.Codes, func(c models.Code) string { return c.Name }, data.Aliases[KindCodes]),
+		materials:  newNameIndex(data.Materials, func(m models.Material) string { return m.Name }, data.Aliases[KindMaterials]),
+		search:     newSearchIndex(data),
+	}
+	for weaponType := range data.Weapons {
+		m.weaponTypes = append(m.weaponTypes, weaponType)
+	}
+	slices.Sort(m.weaponTypes)
+	m.weaponIndex = newNameIndex(m.weaponTypes, func(t string) string { return t }, data.Aliases[KindWeaponTypes])
+	for _, weaponType := range m.weaponTypes {
+		weapons := newNameIndex(data.Weapons[weaponType], func(w models.Weapon) string { return w.Name }, data.Aliases[KindWeapons])
+		m.weapons[weaponType] = weapons
+		m.weaponNames = append(m.weaponNames, weapons.candidates...)
+	}
+	return m
+}
+
+// Check rejects snapshots that are obviously broken: an empty dataset or an
+// entry without a name usually means a file failed to decode as intended.
+func (m *Memory) Check() error {
+	if len(m.data.Characters) == 0 {
+		return fmt.Errorf("no characters loaded")
+	}
+	if len(m.data.Weapons) == 0 {
+		return fmt.Errorf("no weapons loaded")
+	}
+	if len(m.data.Echoes) == 0 {
+		return fmt.Errorf("no echoes loaded")
+	}
+	for i, character := range m.data.Characters {
+		if character.Name == "" {
+			return fmt.Errorf("character %d has no name", i)
+		}
+	}
+	for weaponType, weapons := range m.data.Weapons {
+		for i, weapon := range weapons {
+			if weapon.Name == "" {
+				return fmt.Errorf("weapon %s[%d] has no name", weaponType, i)
+			}
+		}
+	}
+	for i, echo := range m.data.Echoes {
+		if echo.Name == "" {
+			return fmt.Errorf("echo %d has no name", i)
+		}
+	}
+	return nil
+}
+
+// nameIndex resolves slugs, their compact forms and aliases to positions in
+// a slice.
+type nameIndex struct {
+	slots      map[string]int
+	candidates []candidate
+}
+
+func newNameIndex[T any](items []T, name func(T) string, aliases map[string]string) nameIndex {
+	idx := nameIndex{slots: make(map[string]int, 2*len(items))}
+	for i, item := range items {
+		display := strings.ReplaceAll(name(item), "%20", " ")
+		idx.add(Slug(display), display, i)
+	}
+	for alias, target := range aliases {
+		if i, ok := idx.find(target); ok {
+			idx.add(Slug(alias), strings.ReplaceAll(name(items[i]), "%20", " "), i)
+		}
+	}
+	return idx
+}
+
+func (idx *nameIndex) add(slug, display string, i int) {
+	if _, ok := idx.slots[slug]; !ok {
+		idx.slots[slug] = i
+	}
+	if _, ok := idx.slots[compact(slug)]; !ok {
+		idx.slots[compact(slug)] = i
+	}
+	idx.candidates = append(idx.candidates, candidate{slug: slug, name: display})
+}
+
+func (idx nameIndex) find(name string) (int, bool) {
+	slug := Slug(name)
+	if i, ok := idx.slots[slug]; ok {
+		return i, true
+	}
+	i, ok := idx.slots[compact(slug)]
+	return i, ok
+}
+
+func lookup[T any](items []T, idx nameIndex, slug string) (T, bool) {
+	i, ok := idx.find(slug)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return items[i], true
+}
+
+func (m *Memory) Snapshot() Store { return m }
+
+func (m *Memory) Characters() []models.Character { return m.data.Characters }
+
+func (m *Memory) Character(slug string) (models.Character, bool) {
+	return lookup(m.data.Characters, m.characters, slug)
+}
+
+func (m *Memory) EachCharacter() iter.Seq[models.Character] { return slices.Values(m.data.Characters) }
+
+func (m *Memory) Attributes() []models.Attribute { return m.data.Attributes }
+
+func (m *Memory) Attribute(slug string) (models.Attribute, bool) {
+	return lookup(m.data.Attributes, m.attributes, slug)
+}
+
+func (m *Memory) EachAttribute() iter.Seq[models.Attribute] { return slices.Values(m.data.Attributes) }
+
+func (m *Memory) WeaponTypes() []string { return m.weaponTypes }
+
+func (m *Memory) Weapons(weaponType string) ([]models.Weapon, bool) {
+	i, ok := m.weaponIndex.find(weaponType)
+	if !ok {
+		return nil, false
+	}
+	return m.data.Weapons[m.weaponTypes[i]], true
+}
+
+func (m *Memory) Weapon(weaponType, slug string) (models.Weapon, bool) {
+	i, ok := m.weaponIndex.find(weaponType)
+	if !ok {
+		return models.Weapon{}, false
+	}
+	key := m.weaponTypes[i]
+	return lookup(m.data.Weapons[key], m.weapons[key], slug)
+}
+
+func (m *Memory) EachWeapon() iter.Seq2[string, models.Weapon] {
+	return func(yield func(string, models.Weapon) bool) {
+		for _, weaponType := range m.weaponTypes {
+			for _, weapon := range m.data.Weapons[weaponType] {
+				if !yield(weaponType, weapon) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (m *Memory) Echoes() []models.Echo { return m.data.Echoes }
+
+func (m *Memory) Echo(slug string) (models.Echo, bool) {
+	return lookup(m.data.Echoes, m.echoes, slug)
+}
+
+func (m *Memory) EachEcho() iter.Seq[models.Echo] { return slices.Values(m.data.Echoes) }
+
+func (m *Memory) Sonatas() []models.Sonata { return m.data.Sonatas }
+
+func (m *Memory) Sonata(slug string) (models.Sonata, bool) {
+	return lookup(m.data.Sonatas, m.sonatas, slug)
+}
+
+func (m *Memory) EachSonata() iter.Seq[models.Sonata] { return slices.Values(m.data.Sonatas) }
+
+func (m *Memory) Stats() []models.Stat { return m.data.Stats }
+
+func (m *Memory) Stat(slug string) (models.Stat, bool) {
+	return lookup(m.data.Stats, m.stats, slug)
+}
+
+func (m *Memory) EachStat() iter.Seq[models.Stat] { return slices.Values(m.data.Stats) }
+
+func (m *Memory) Substats() []models.Substat { return m.data.Substats }
+
+func (m *Memory) Substat(slug string) (models.Substat, bool) {
+	return lookup(m.data.Substats, m.substats, slug)
+}
+
+func (m *Memory) EachSubstat() iter.Seq[models.Substat] { return slices.Values(m.data.Substats) }
+
+func (m *Memory) Codes() []models.Code { return m.data.Codes }
+
+func (m *Memory) Code(slug string) (models.Code, bool) {
+	return lookup(m.data.Codes, m.codes, slug)
+}
+
+func (m *Memory) EachCode() iter.Seq[models.Code] { return slices.Values(m.data.Codes) }
+
+func (m *Memory) Materials() []models.Material { return m.data.Materials }
+
+func (m *Memory) Material(slug string) (models.Material, bool) {
+	return lookup(m.data.Materials, m.materials, slug)
+}
+
+func (m *Memory) EachMaterial() iter.Seq[models.Material] { return slices.Values(m.data.Materials) }
+
+func (m *Memory) Costs() models.Costs { return m.data.Costs }
+
+func (m *Memory) Search(query string) []SearchHit { return m.search.search(query) }
+
+func (m *Memory) Suggest(kind Kind, name string) []string {
+	switch kind {
+	case KindCharacters:
+		return suggest(m.characters.candidates, name)
+	case KindAttributes:
+		return suggest(m.attributes.candidates, name)
+	case KindWeaponTypes:
+		return suggest(m.weaponIndex.candidates, name)
+	case KindWeapons:
+		return suggest(m.weaponNames, name)
+	case KindEchoes:
+		return suggest(m.echoes.candidates, name)
+	case KindSonatas:
+		return suggest(m.sonatas.candidates, name)
+	case KindStats:
+		return suggest(m.stats.candidates, name)
+	case KindSubstats:
+		return suggest(m.substats.candidates, name)
+	case KindCodes:
+		return suggest(m.codes.candidates, name)
+	case KindMaterials:
+		return suggest(m.materials.candidates, name)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"slices"
+	"testing"
+
+	"api/models"
+)
+
+func fixture() Data {
+	return Data{
+		Characters: []models.Character{{Name: "Jiyan"}, {Name: "Xiangli Yao"}, {Name: "Rover"}},
+		Weapons: map[string][]models.Weapon{
+			"Sword":     {{Name: "Emerald of Genesis"}},
+			"Gauntlets": {{Name: "Verity's Handle"}},
+		},
+		Echoes: []models.Echo{{Name: "Crownless"}},
+		Aliases: map[Kind]map[string]string{
+			KindCharacters:  {"XLY": "Xiangli Yao"},
+			KindWeaponTypes: {"Gauntlet": "Gauntlets"},
+		},
+	}
+}
+
+func TestMemoryLookup(t *testing.T) {
+	m := NewMemory(fixture())
+
+	for _, name := range []string{"Xiangli Yao", "xiangli_yao", "xiangli-yao", "Xiangli%20Yao", "xiangliyao", "xly"} {
+		character, ok := m.Character(name)
+		if !ok || character.Name != "Xiangli Yao" {
+			t.Errorf("Character(%q) = %q, %v, want Xiangli Yao", name, character.Name, ok)
+		}
+	}
+	if _, ok := m.Character("Calcharo"); ok {
+		t.Errorf("Character(Calcharo) found a character missing from the data")
+	}
+
+	if weapon, ok := m.Weapon("gauntlet", "verity's handle"); !ok || weapon.Name != "Verity's Handle" {
+		t.Errorf("Weapon(gauntlet, verity's handle) = %q, %v", weapon.Name, ok)
+	}
+	if _, ok := m.Weapon("sword", "verity's handle"); ok {
+		t.Errorf("Weapon found a gauntlet among the swords")
+	}
+	if types := m.WeaponTypes(); !slices.Equal(types, []string{"Gauntlets", "Sword"}) {
+		t.Errorf("WeaponTypes() = %v, want them sorted", types)
+	}
+}
+
+func TestMemorySuggest(t *testing.T) {
+	m := NewMemory(fixture())
+	if got := m.Suggest(KindCharacters, "jiyna"); len(got) == 0 || got[0] != "Jiyan" {
+		t.Errorf("Suggest(jiyna) = %v, want Jiyan first", got)
+	}
+}
+
+func TestMemoryCheck(t *testing.T) {
+	if err := NewMemory(fixture()).Check(); err != nil {
+		t.Errorf("Check() = %v on a complete fixture", err)
+	}
+
+	data := fixture()
+	data.Characters = nil
+	if err := NewMemory(data).Check(); err == nil {
+		t.Errorf("Check() accepted a dataset without characters")
+	}
+
+	data = fixture()
+	data.Echoes = append(data.Echoes, models.Echo{})
+	if err := NewMemory(data).Check(); err == nil {
+		t.Errorf("Check() accepted an echo without a name")
+	}
+}
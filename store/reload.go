@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"iter"
+	"log"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"api/models"
+)
+
+// Reloader is a Store whose data can be replaced while the server is running.
+// Each reload builds a complete new snapshot and swaps it in atomically, so
+// requests that already took a snapshot finish on the old data. A reload that
+// fails to load or validate leaves the current snapshot in place.
+type Reloader struct {
+	load     func() (*Memory, error)
+	validate func(*Memory) error
+
+	current atomic.Pointer[Memory]
+
+	mu     sync.Mutex
+	status ReloadStatus
+}
+
+// ReloadStatus describes the outcome of the most recent reload attempt.
+type ReloadStatus struct {
+	LoadedAt    time.Time `json:"loadedAt"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// NewReloader performs the initial load and returns a Reloader serving it.
+// validate may be nil; otherwise every snapshot must pass it before it is
+// served, including the first one.
+func NewReloader(load func() (*Memory, error), validate func(*Memory) error) (*Reloader, error) {
+	r := &Reloader{load: load, validate: validate}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads and validates a new snapshot and swaps it in.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.status.AttemptedAt = now
+
+	next, err := r.load()
+	if err == nil && r.validate != nil {
+		err = r.validate(next)
+	}
+	if err != nil {
+		r.status.Error = err.Error()
+		return err
+	}
+
+	r.current.Store(next)
+	r.status.LoadedAt = now
+	r.status.Error = ""
+	return nil
+}
+
+// Status reports when the data was last loaded and why the latest attempt
+// failed, if it did.
+func (r *Reloader) Status() ReloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Watch polls the files under dir every interval and reloads when any of them
+// changes. A reload that fails is retried on every tick until one succeeds,
+// so a file fixed in place is picked up even if it looks unchanged since the
+// failure. It returns when ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, err := fingerprint(dir)
+	if err != nil {
+		log.Printf("Error watching %s: %v", dir, err)
+	}
+	var failed string
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := fingerprint(dir)
+		if err != nil {
+			log.Printf("Error watching %s: %v", dir, err)
+			continue
+		}
+		if current == last {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			if err.Error() != failed {
+				log.Printf("Error reloading data, keeping previous snapshot: %v", err)
+				failed = err.Error()
+			}
+			continue
+		}
+		last, failed = current, ""
+		log.Printf("Reloaded data from %s", dir)
+	}
+}
+
+// fingerprint summarises the names, sizes and modification times of every
+// file under dir.
+func fingerprint(dir string) (string, error) {
+	var sum string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum += fmt.Sprintf("%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return sum, err
+}
+
+func (r *Reloader) Snapshot() Store { return r.current.Load() }
+
+func (r *Reloader) Characters() []models.Character { return r.current.Load().Characters() }
+
+func (r *Reloader) Character(slug string) (models.Character, bool) {
+	return r.current.Load().Character(slug)
+}
+
+func (r *Reloader) EachCharacter() iter.Seq[models.Character] {
+	return r.current.Load().EachCharacter()
+}
+
+func (r *Reloader) Attributes() []models.Attribute { return r.current.Load().Attributes() }
+
+func (r *Reloader) Attribute(slug string) (models.Attribute, bool) {
+	return r.current.Load().Attribute(slug)
+}
+
+func (r *Reloader) EachAttribute() iter.Seq[models.Attribute] {
+	return r.current.Load().EachAttribute()
+}
+
+func (r *Reloader) WeaponTypes() []string { return r.current.Load().WeaponTypes() }
+
+func (r *Reloader) Weapons(weaponType string) ([]models.Weapon, bool) {
+	return r.current.Load().Weapons(weaponType)
+}
+
+func (r *Reloader) Weapon(weaponType, slug string) (models.Weapon, bool) {
+	return r.current.Load().Weapon(weaponType, slug)
+}
+
+func (r *Reloader) EachWeapon() iter.Seq2[string, models.Weapon] {
+	return r.current.Load().EachWeapon()
+}
+
+func (r *Reloader) Echoes() []models.Echo { return r.current.Load().Echoes() }
+
+func (r *Reloader) Echo(slug string) (models.Echo, bool) { return r.current.Load().Echo(slug) }
+
+func (r *Reloader) EachEcho() iter.Seq[models.Echo] { return r.current.Load().EachEcho() }
+
+func (r *Reloader) Sonatas() []models.Sonata { return r.current.Load().Sonatas() }
+
+func (r *Reloader) Sonata(slug string) (models.Sonata, bool) {
+	return r.current.Load().Sonata(slug)
+}
+
+func (r *Reloader) EachSonata() iter.Seq[models.Sonata] { return r.current.Load().EachSonata() }
+
+func (r *Reloader) Stats() []models.Stat { return r.current.Load().Stats() }
+
+func (r *Reloader) Stat(slug string) (models.Stat, bool) { return r.current.Load().Stat(slug) }
+
+func (r *Reloader) EachStat() iter.Seq[models.Stat] { return r.current.Load().EachStat() }
+
+func (r *Reloader) Substats() []models.Substat { return r.current.Load().Substats() }
+
+func (r *Reloader) Substat(slug string) (models.Substat, bool) {
+	return r.current.Load().Substat(slug)
+}
+
+func (r *Reloader) EachSubstat() iter.Seq[models.Substat] { return r.current.Load().EachSubstat() }
+
+func (r *Reloader) Codes() []models.Code { return r.current.Load().Codes() }
+
+func (r *Reloader) Code(slug string) (models.Code, bool) { return r.current.Load().Code(slug) }
+
+func (r *Reloader) EachCode() iter.Seq[models.Code] { return r.current.Load().EachCode() }
+
+func (r *Reloader) Materials() []models.Material { return r.current.Load().Materials() }
+
+func (r *Reloader) Material(slug string) (models.Material, bool) {
+	return r.current.Load().Material(slug)
+}
+
+func (r *Reloader) EachMaterial() iter.Seq[models.Material] { return r.current.Load().EachMaterial() }
+
+func (r *Reloader) Costs() models.Costs { return r.current.Load().Costs() }
+
+func (r *Reloader) Search(query string) []SearchHit { return r.current.Load().Search(query) }
+
+func (r *Reloader) Suggest(kind Kind, name string) []string {
+	return r.current.Load().Suggest(kind, name)
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"api/models"
+)
+
+func TestReloaderKeepsSnapshotOnFailure(t *testing.T) {
+	first := NewMemory(fixture())
+	var next func() (*Memory, error)
+	r, err := NewReloader(func() (*Memory, error) {
+		if next == nil {
+			return first, nil
+		}
+		return next()
+	}, (*Memory).Check)
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+
+	snapshot := r.Snapshot()
+	next = func() (*Memory, error) { return nil, errors.New("half-saved file") }
+	if err := r.Reload(); err == nil {
+		t.Fatalf("Reload() succeeded with a failing load")
+	}
+	if r.Snapshot() != Store(first) {
+		t.Errorf("a failed load replaced the snapshot")
+	}
+	if status := r.Status(); status.Error != "half-saved file" {
+		t.Errorf("Status().Error = %q, want the load error", status.Error)
+	}
+
+	next = func() (*Memory, error) { return NewMemory(Data{}), nil }
+	if err := r.Reload(); err == nil {
+		t.Fatalf("Reload() served a snapshot that fails validation")
+	}
+	if r.Snapshot() != Store(first) {
+		t.Errorf("an invalid snapshot replaced the current one")
+	}
+
+	data := fixture()
+	data.Characters = append(data.Characters, models.Character{Name: "Changli"})
+	next = func() (*Memory, error) { return NewMemory(data), nil }
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload(): %v", err)
+	}
+	if _, ok := r.Character("changli"); !ok {
+		t.Errorf("the reloaded data is not served")
+	}
+	if _, ok := snapshot.Character("changli"); ok {
+		t.Errorf("a snapshot taken before the reload changed")
+	}
+	if status := r.Status(); status.Error != "" {
+		t.Errorf("Status().Error = %q after a successful reload", status.Error)
+	}
+}
+
+func TestNewReloaderFails(t *testing.T) {
+	_, err := NewReloader(func() (*Memory, error) { return NewMemory(Data{}), nil }, (*Memory).Check)
+	if err == nil {
+		t.Errorf("NewReloader() accepted an empty dataset")
+	}
+}
+
+func TestWatchRetriesFailedReload(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "codes.json"), []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		fail  bool
+		loads int
+	)
+	r, err := NewReloader(func() (*Memory, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads++
+		if fail {
+			return nil, errors.New("half-saved file")
+		}
+		return NewMemory(fixture()), nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, dir, 5*time.Millisecond)
+
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	// Keep touching the file until the watcher, which may not have taken its
+	// first fingerprint yet, notices.
+	later := time.Now()
+	waitFor(t, func() bool {
+		later = later.Add(time.Second)
+		if err := os.Chtimes(filepath.Join(dir, "codes.json"), later, later); err != nil {
+			t.Fatal(err)
+		}
+		return r.Status().Error != ""
+	})
+
+	// Let the watcher catch up with the last touch, then fix the file without
+	// changing it again; the watcher must retry.
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	waitFor(t, func() bool { return r.Status().Error == "" })
+
+	mu.Lock()
+	settled := loads
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if loads != settled {
+		t.Errorf("the watcher kept reloading unchanged files after a successful reload")
+	}
+}
+
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
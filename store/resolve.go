@@ -0,0 +1,121 @@
+package store
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Kind names a dataset for Suggest.
+type Kind string
+
+const (
+	KindCharacters  Kind = "characters"
+	KindAttributes  Kind = "attributes"
+	KindWeaponTypes Kind = "weaponTypes"
+	KindWeapons     Kind = "weapons"
+	KindEchoes      Kind = "echoes"
+	KindSonatas     Kind = "sonatas"
+	KindStats       Kind = "stats"
+	KindSubstats    Kind = "substats"
+	KindCodes       Kind = "codes"
+	KindMaterials   Kind = "materials"
+)
+
+var stripMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// Slug normalises a name or route parameter so that "Xiangli Yao",
+// "xiangli_yao", "xiangli-yao" and "Xiangli%20Yao" all resolve to the same
+// entry. URL escapes are decoded, diacritics dropped, and every run of
+// characters other than letters, digits and '%' becomes a single space.
+func Slug(name string) string {
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if folded, _, err := transform.String(stripMarks, name); err == nil {
+		name = folded
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '%'
+	})
+	return strings.Join(words, " ")
+}
+
+// compact drops the spaces from a slug so "xiangliyao" still matches.
+func compact(slug string) string {
+	return strings.ReplaceAll(slug, " ", "")
+}
+
+// candidate is a name Suggest may offer, keyed by the slug it is compared on.
+// Aliases are candidates whose slug differs from the name they resolve to.
+type candidate struct {
+	slug string
+	name string
+}
+
+func suggest(candidates []candidate, query string) []string {
+	const limit = 5
+
+	q := Slug(query)
+	if q == "" {
+		return nil
+	}
+	threshold := max(2, len(q)/2)
+
+	best := make(map[string]int)
+	for _, c := range candidates {
+		d := distance(q, c.slug)
+		if len(c.slug) > len(q) {
+			d = min(d, distance(q, c.slug[:len(q)])+1)
+		}
+		if d > threshold {
+			continue
+		}
+		if prev, ok := best[c.name]; !ok || d < prev {
+			best[c.name] = d
+		}
+	}
+
+	names := make([]string, 0, len(best))
+	for name := range best {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if n := cmp.Compare(best[a], best[b]); n != 0 {
+			return n
+		}
+		return cmp.Compare(a, b)
+	})
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	return names
+}
+
+// distance is the Levenshtein edit distance between a and b, in bytes.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
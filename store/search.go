@@ -0,0 +1,275 @@
+package store
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// SearchHit is a single search result, pointing at the detail route of the
+// entry that matched.
+type SearchHit struct {
+	Type    string  `json:"type"`
+	Name    string  `json:"name"`
+	Field   string  `json:"field"`
+	Snippet string  `json:"snippet"`
+	Link    string  `json:"link"`
+	Score   float64 `json:"score"`
+}
+
+type searchField struct {
+	name   string
+	text   string
+	weight float64
+}
+
+type searchDoc struct {
+	kind   string
+	name   string
+	link   string
+	fields []searchField
+}
+
+type posting struct {
+	doc   int
+	field int
+	count int
+}
+
+// searchIndex is an inverted index from lowercased tokens to the document
+// fields containing them. It is built once per snapshot.
+type searchIndex struct {
+	docs     []searchDoc
+	postings map[string][]posting
+	tokens   []string
+}
+
+func newSearchIndex(data Data) *searchIndex {
+	idx := &searchIndex{postings: make(map[string][]posting)}
+
+	for _, character := range data.Characters {
+		name := strings.ReplaceAll(character.Name, "%20", " ")
+		idx.add(searchDoc{
+			kind: "character",
+			name: name,
+			link: "/characters/" + linkSlug(name),
+			fields: []searchField{
+				{"name", name, 5},
+				{"attribute", character.Attribute, 2},
+				{"weapon", character.Weapon, 2},
+				{"quote", character.Quote, 1},
+			},
+		})
+	}
+
+	types := make([]string, 0, len(data.Weapons))
+	for weaponType := range data.Weapons {
+		types = append(types, weaponType)
+	}
+	slices.Sort(types)
+	for _, weaponType := range types {
+		for _, weapon := range data.Weapons[weaponType] {
+			idx.add(searchDoc{
+				kind: "weapon",
+				name: weapon.Name,
+				link: "/weapons/" + linkSlug(weaponType) + "/" + linkSlug(weapon.Name),
+				fields: []searchField{
+					{"name", weapon.Name, 5},
+					{"skill.name", weapon.Skill.Name, 2},
+					{"skill.description", weapon.Skill.Description, 1},
+					{"description", weapon.Description, 1},
+				},
+			})
+		}
+	}
+
+	for _, echo := range data.Echoes {
+		idx.add(searchDoc{
+			kind: "echo",
+			name: echo.Name,
+			link: "/echoes/" + linkSlug(echo.Name),
+			fields: []searchField{
+				{"name", echo.Name, 5},
+				{"outline", echo.Outline, 1.5},
+				{"description", echo.Description, 1},
+			},
+		})
+	}
+
+	for _, sonata := range data.Sonatas {
+		idx.add(searchDoc{
+			kind: "sonata",
+			name: sonata.Name,
+			link: "/echoes/sonatas/" + linkSlug(sonata.Name),
+			fields: []searchField{
+				{"name", sonata.Name, 5},
+				{"twoPiece", sonata.TwoPiece, 1},
+				{"fivePiece", sonata.FivePiece, 1},
+			},
+		})
+	}
+
+	for token := range idx.postings {
+		idx.tokens = append(idx.tokens, token)
+	}
+	slices.Sort(idx.tokens)
+	return idx
+}
+
+func (idx *searchIndex) add(doc searchDoc) {
+	id := len(idx.docs)
+	idx.docs = append(idx.docs, doc)
+
+	for f, field := range doc.fields {
+		counts := make(map[string]int)
+		for _, token := range tokenize(field.text) {
+			counts[token]++
+		}
+		for token, count := range counts {
+			idx.postings[token] = append(idx.postings[token], posting{doc: id, field: f, count: count})
+		}
+	}
+}
+
+// search returns the documents containing every term of query, ranked by a
+// field-weighted tf-idf score. The last term also matches as a prefix so
+// partially typed words still find results.
+func (idx *searchIndex) search(query string) []SearchHit {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	scores := make(map[int]float64)
+	best := make(map[int]int)
+	bestScore := make(map[int]float64)
+
+	for i, term := range terms {
+		matches := []string{term}
+		if i == len(terms)-1 {
+			matches = idx.withPrefix(term)
+		}
+
+		termScores := make(map[int]float64)
+		for _, token := range matches {
+			postings := idx.postings[token]
+			if len(postings) == 0 {
+				continue
+			}
+			idf := math.Log(1 + float64(len(idx.docs))/float64(len(postings)))
+			for _, p := range postings {
+				score := idx.docs[p.doc].fields[p.field].weight * float64(p.count) * idf
+				if token != term {
+					score /= 2
+				}
+				termScores[p.doc] += score
+				if score > bestScore[p.doc] {
+					bestScore[p.doc] = score
+					best[p.doc] = p.field
+				}
+			}
+		}
+
+		if i == 0 {
+			scores = termScores
+			continue
+		}
+		for doc := range scores {
+			if s, ok := termScores[doc]; ok {
+				scores[doc] += s
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for id, score := range scores {
+		doc := idx.docs[id]
+		field := doc.fields[best[id]]
+		hits = append(hits, SearchHit{
+			Type:    doc.kind,
+			Name:    doc.name,
+			Field:   field.name,
+			Snippet: snippet(field.text, terms),
+			Link:    doc.link,
+			Score:   math.Round(score*100) / 100,
+		})
+	}
+
+	slices.SortFunc(hits, func(a, b SearchHit) int {
+		if n := cmp.Compare(b.Score, a.Score); n != 0 {
+			return n
+		}
+		if n := cmp.Compare(a.Type, b.Type); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return hits
+}
+
+func (idx *searchIndex) withPrefix(prefix string) []string {
+	start, _ := slices.BinarySearch(idx.tokens, prefix)
+	var tokens []string
+	for _, token := range idx.tokens[start:] {
+		if !strings.HasPrefix(token, prefix) {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// snippet returns up to about 120 characters of text around the first
+// occurrence of any of terms.
+func snippet(text string, terms []string) string {
+	const width = 120
+
+	lower := strings.ToLower(text)
+	at := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+
+	start := 0
+	if at > 0 {
+		start = len([]rune(text[:at])) - width/3
+	}
+	start = max(0, min(start, len(runes)-width))
+	if start > 0 {
+		if i := slices.Index(runes[start:], ' '); i >= 0 && i < width/3 {
+			start += i + 1
+		}
+	}
+	end := min(start+width, len(runes))
+
+	out := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(runes) {
+		out += "…"
+	}
+	return out
+}
+
+// linkSlug turns a name into the form used in detail route links.
+func linkSlug(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), " ", "_")
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"api/models"
+)
+
+func searchData() Data {
+	return Data{
+		Characters: []models.Character{
+			{Name: "Jiyan", Attribute: "Aero", Weapon: "Broadblade", Quote: "I have never regretted to brave the long night."},
+			{Name: "Jianxin", Attribute: "Aero", Weapon: "Gauntlets"},
+			{Name: "Calcharo", Attribute: "Electro", Weapon: "Broadblade"},
+		},
+		Weapons: map[string][]models.Weapon{
+			"Broadblade": {{Name: "Verdant Summit", Type: "Broadblade", Description: "A broadblade forged in the night."}},
+		},
+		Echoes: []models.Echo{
+			{Name: "Feilian Beringal", Outline: "Summons a Feilian Beringal to deal Aero DMG."},
+		},
+		Sonatas: []models.Sonata{{Name: "Sierra Gale", TwoPiece: "Aero DMG +10%."}},
+	}
+}
+
+func hitNames(hits []SearchHit) []string {
+	var names []string
+	for _, hit := range hits {
+		names = append(names, hit.Type+":"+hit.Name)
+	}
+	return names
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := newSearchIndex(searchData())
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Names weigh more than other fields, and equal scores are ordered by
+		// type and then name.
+		{"jiyan", []string{"character:Jiyan"}},
+		{"broadblade", []string{"character:Calcharo", "character:Jiyan", "weapon:Verdant Summit"}},
+		{"aero", []string{"character:Jianxin", "character:Jiyan", "echo:Feilian Beringal", "sonata:Sierra Gale"}},
+		{"night", []string{"character:Jiyan", "weapon:Verdant Summit"}},
+		// Every term has to match.
+		{"aero broadblade", []string{"character:Jiyan"}},
+		{"electro gauntlets", nil},
+		// Case and punctuation are ignored.
+		{"  SIERRA, gale!", []string{"sonata:Sierra Gale"}},
+		{"", nil},
+		{"?!", nil},
+	}
+	for _, tt := range tests {
+		if got := hitNames(idx.search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchScores(t *testing.T) {
+	idx := newSearchIndex(searchData())
+
+	hits := idx.search("feilian")
+	if len(hits) != 1 || hits[0].Field != "name" || hits[0].Link != "/echoes/feilian_beringal" {
+		t.Fatalf("search(feilian) = %+v, want the echo matched on its name", hits)
+	}
+	// The name outweighs the outline mention.
+	if outline := idx.search("summons"); len(outline) != 1 || outline[0].Score >= hits[0].Score {
+		t.Errorf("search(summons) = %+v, want a lower score than a name match %g", outline, hits[0].Score)
+	}
+
+	aero := idx.search("aero")
+	for i, hit := range aero {
+		if i > 0 && hit.Score > aero[i-1].Score {
+			t.Errorf("search(aero) is not ranked best first at %d", i)
+		}
+	}
+}
+
+func TestSearchPrefix(t *testing.T) {
+	idx := newSearchIndex(searchData())
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// The last term matches as a prefix, so partly typed words find results.
+		{"ji", []string{"character:Jianxin", "character:Jiyan"}},
+		{"jiy", []string{"character:Jiyan"}},
+		{"verdant sum", []string{"weapon:Verdant Summit"}},
+		// Earlier terms must match whole tokens.
+		{"verd summit", nil},
+		{"jiyanx", nil},
+	}
+	for _, tt := range tests {
+		if got := hitNames(idx.search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// A whole word counts twice as much as a prefix of a longer one.
+	whole, prefix := idx.search("jiyan"), idx.search("jiya")
+	if len(whole) != 1 || len(prefix) != 1 || math.Abs(whole[0].Score-2*prefix[0].Score) > 0.01 {
+		t.Errorf("search(jiyan) = %+v, search(jiya) = %+v, want the prefix at half the score", whole, prefix)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("filler words ", 20) + "the Resonance Liberation deals Aero DMG " + strings.Repeat("more text ", 20)
+	got := snippet(long, []string{"liberation"})
+	if !strings.Contains(got, "Resonance Liberation") || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet() = %q, want the text around the match, elided on both sides", got)
+	}
+	if got := snippet("Short text", []string{"text"}); got != "Short text" {
+		t.Errorf("snippet(short) = %q", got)
+	}
+}
+
+func TestSuggestFuzzy(t *testing.T) {
+	candidates := []candidate{
+		{"jiyan", "Jiyan"}, {"jianxin", "Jianxin"}, {"jinhsi", "Jinhsi"},
+		{"calcharo", "Calcharo"}, {"rover", "Rover"}, {"the-rover", "Rover"},
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"jiyan", []string{"Jiyan"}},
+		{"jyan", []string{"Jiyan", "Jianxin"}},
+		{"jiyna", []string{"Jiyan"}},
+		{"calcaro", []string{"Calcharo"}},
+		// A prefix of a longer name counts as one edit.
+		{"calch", []string{"Calcharo"}},
+		// Aliases suggest the name they stand for, once.
+		{"rovr", []string{"Rover"}},
+		{"xxxxxx", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := suggest(candidates, tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("suggest(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"jiyan", "jiyan", 0},
+		{"jiyan", "jyan", 1},
+		{"jiyan", "jiyna", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := distance(tt.b, tt.a); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"iter"
+
+	"api/models"
+)
+
+// Store is the read-only view of the game data that every handler is served
+// from. Lookups take a slug (see Slug), lists return the entries in load
+// order (weapon types alphabetically) and must not be modified by the caller.
+type Store interface {
+	// Snapshot returns a Store that keeps serving the same data for its
+	// whole lifetime, even if the receiver is reloaded in the meantime.
+	// Handlers that read more than once per request should work on a
+	// snapshot so they never mix two versions of the dataset.
+	Snapshot() Store
+
+	Characters() []models.Character
+	Character(slug string) (models.Character, bool)
+	EachCharacter() iter.Seq[models.Character]
+
+	Attributes() []models.Attribute
+	Attribute(slug string) (models.Attribute, bool)
+	EachAttribute() iter.Seq[models.Attribute]
+
+	WeaponTypes() []string
+	Weapons(weaponType string) ([]models.Weapon, bool)
+	Weapon(weaponType, slug string) (models.Weapon, bool)
+	EachWeapon() iter.Seq2[string, models.Weapon]
+
+	Echoes() []models.Echo
+	Echo(slug string) (models.Echo, bool)
+	EachEcho() iter.Seq[models.Echo]
+
+	Sonatas() []models.Sonata
+	Sonata(slug string) (models.Sonata, bool)
+	EachSonata() iter.Seq[models.Sonata]
+
+	Stats() []models.Stat
+	Stat(slug string) (models.Stat, bool)
+	EachStat() iter.Seq[models.Stat]
+
+	Substats() []models.Substat
+	Substat(slug string) (models.Substat, bool)
+	EachSubstat() iter.Seq[models.Substat]
+
+	Codes() []models.Code
+	Code(slug string) (models.Code, bool)
+	EachCode() iter.Seq[models.Code]
+
+	Materials() []models.Material
+	Material(slug string) (models.Material, bool)
+	EachMaterial() iter.Seq[models.Material]
+
+	// Costs returns the EXP and credits needed to level characters and
+	// weapons up.
+	Costs() models.Costs
+
+	// Suggest returns the names of kind closest to name, best first, for
+	// lookups that found nothing.
+	Suggest(kind Kind, name string) []string
+
+	// Search runs a full-text query over characters, weapons, echoes and
+	// sonatas and returns the hits ranked best first.
+	Search(query string) []SearchHit
+}
@@ -38,8 +38,12 @@ func LoadCharacters(dirPath string) ([]models.Character, error) {
 		if err := loadJSONFile(filePath, &character); err != nil {
 			return nil, fmt.Errorf("error loading character from %s: %v", file.Name(), err)
 		}
+		if err := character.Check(); err != nil {
+			return nil, fmt.Errorf("invalid character in %s: %v", file.Name(), err)
+		}
 
 		character.Name = strings.ReplaceAll(character.Name, " ", "%20")
+		character.Source = filePath
 
 		characters = append(characters, character)
 	}
@@ -87,4 +91,22 @@ func LoadCodes(filename string) ([]models.Code, error) {
 	var codes []models.Code
 	err := loadJSONFile(filename, &codes)
 	return codes, err
-}
\ No newline at end of file
+}
+
+func LoadMaterials(filename string) ([]models.Material, error) {
+	var materials []models.Material
+	err := loadJSONFile(filename, &materials)
+	return materials, err
+}
+
+func LoadCosts(filename string) (models.Costs, error) {
+	var costs models.Costs
+	err := loadJSONFile(filename, &costs)
+	return costs, err
+}
+
+func LoadAliases(filename string) (map[string]map[string]string, error) {
+	var aliases map[string]map[string]string
+	err := loadJSONFile(filename, &aliases)
+	return aliases, err
+}
@@ -2,48 +2,116 @@ package utils
 
 import (
 	"github.com/gin-gonic/gin"
+	"api/apikeys"
+	"api/ratelimit"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 	"net/url"
 )
 
-var (
-	rateLimit    = 200
-	requestCount = make(map[string]int)
-	mutex        sync.Mutex
-)
-
-func RateLimitMiddleware() gin.HandlerFunc {
+// RateLimitMiddleware takes each request's route cost from the client's
+// bucket and answers 429 once it runs dry. Requests with a valid API key
+// draw on the key's bucket and daily quota; other requests are limited per
+// client IP. Every response carries the client's limit, the requests it has
+// left and the seconds until its bucket is full again; a refused request
+// also gets Retry-After.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, keys *apikeys.Keyring) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
+		now := time.Now()
+		bucket, limit := "ip:"+c.ClientIP(), limiter.Limit
 
-		mutex.Lock()
-		defer mutex.Unlock()
+		cost := limiter.Cost(c.Request.Method + " " + c.FullPath())
 
-		requestCount[ip]++
+		var grant *apikeys.Grant
+		if token := c.GetHeader(apikeys.Header); token != "" && keys != nil {
+			g, err := keys.Lookup(token)
+			if err != nil {
+				// Bad keys are charged to the client's IP, so guessing keys
+				// is limited like any other request.
+				if !takeTokens(c, limiter, bucket, limit, cost) {
+					return
+				}
+				message := "Invalid API key"
+				if errors.Is(err, apikeys.ErrRevoked) {
+					message = "API key revoked"
+				}
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"status": "error",
+					"error": message,
+				})
+				c.Abort()
+				return
+			}
+			grant = &g
+			bucket, limit = "key:"+g.Key.ID, g.Limit
+		}
 
-		if requestCount[ip] > rateLimit {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"status": "error",
-				"error": "Rate limit exceeded",
-			})
-			c.Abort()
+		if !takeTokens(c, limiter, bucket, limit, cost) {
 			return
 		}
 
-		go func() {
-			time.Sleep(time.Minute)
-			mutex.Lock()
-			defer mutex.Unlock()
-			requestCount[ip] = 0
-		}()
+		// The quota is checked and counted at once, after the rate limit, so
+		// only requests that are served count towards it.
+		if grant != nil {
+			used, ok := keys.Consume(grant.Key.ID, now, grant.Daily)
+			if grant.Daily > 0 {
+				reset := ceilSeconds(apikeys.NextDay(now).Sub(now))
+				c.Header("X-Quota-Limit", strconv.Itoa(grant.Daily))
+				c.Header("X-Quota-Remaining", strconv.Itoa(max(grant.Daily-used, 0)))
+				c.Header("X-Quota-Reset", strconv.Itoa(reset))
+				if !ok {
+					tooManyRequests(c, "Daily quota exceeded", reset)
+					return
+				}
+			}
+		}
 
 		c.Next()
 	}
 }
 
+// takeTokens takes cost tokens from bucket, setting the rate limit headers,
+// and answers 429 when there are not enough. It reports whether the request
+// may go on.
+func takeTokens(c *gin.Context, limiter *ratelimit.Limiter, bucket string, limit ratelimit.Limit, cost float64) bool {
+	if limit.Unlimited() {
+		return true
+	}
+	result, err := limiter.AllowLimit(c.Request.Context(), bucket, limit, cost)
+	if err != nil {
+		// A backend outage should not take the API down with it.
+		log.Printf("Rate limit backend failed, letting request through: %v", err)
+		return true
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		tooManyRequests(c, "Rate limit exceeded", max(ceilSeconds(result.RetryAfter), 1))
+		return false
+	}
+	return true
+}
+
+func tooManyRequests(c *gin.Context, message string, retryAfter int) {
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"status": "error",
+		"error": message,
+	})
+	c.Abort()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
 func LowercaseMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Request.URL.Path = strings.ToLower(c.Request.URL.Path)
@@ -59,4 +127,22 @@ func LowercaseMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+func AdminTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The comparison takes the same time wherever the header differs,
+		// so the token can't be guessed byte by byte.
+		given := []byte(c.GetHeader("Authorization"))
+		if token == "" || subtle.ConstantTimeCompare(given, []byte("Bearer "+token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error": "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
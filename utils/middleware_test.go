@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"api/apikeys"
+	"api/ratelimit"
+)
+
+func TestRateLimitMiddlewareChargesBadKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := apikeys.Open(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+	limiter := ratelimit.New(ratelimit.Limit{Requests: 2, Window: time.Hour, Burst: 2})
+	r := gin.New()
+	r.Use(RateLimitMiddleware(limiter, keys))
+	r.GET("/codes", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/codes", nil)
+		if key != "" {
+			req.Header.Set(apikeys.Header, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if code := get("rr_guess"); code != want {
+			t.Errorf("guess %d = %d, want %d", i+1, code, want)
+		}
+	}
+	if code := get(""); code != http.StatusTooManyRequests {
+		t.Errorf("request without a key after the guesses = %d, want the IP limited", code)
+	}
+}
+
+func TestAdminTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", AdminTokenMiddleware("secret"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for header, want := range map[string]int{
+		"Bearer secret": http.StatusOK,
+		"Bearer secre":  http.StatusUnauthorized,
+		"Bearer":        http.StatusUnauthorized,
+		"":              http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("Authorization %q = %d, want %d", header, w.Code, want)
+		}
+	}
+}
+
+func TestAdminTokenMiddlewareWithoutToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", AdminTokenMiddleware(""), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, header := range []string{"Bearer ", "Bearer", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q without a token = %d, want %d", header, w.Code, http.StatusUnauthorized)
+		}
+	}
+}
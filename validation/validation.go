@@ -0,0 +1,397 @@
+package validation
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"api/calc"
+	"api/models"
+	"api/store"
+)
+
+// Issue is a single problem found in the dataset, located by file and JSON
+// path so it can be fixed without searching.
+type Issue struct {
+	File    string `json:"file"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.File, i.Path, i.Message)
+}
+
+var placeholderPattern = regexp.MustCompile(`\{(\d+)\}`)
+
+// multiAttribute is the attribute of characters, such as the Rover, who can
+// take more than one attribute. It has no entry in attributes.json.
+const multiAttribute = "Multi"
+
+type validator struct {
+	s      store.Store
+	dir    string
+	issues []Issue
+}
+
+// Validate checks the cross references between the data files loaded from
+// dir and returns every issue it finds, in file order.
+func Validate(s store.Store, dir string) []Issue {
+	v := &validator{s: s.Snapshot(), dir: dir}
+	v.characters()
+	v.attributes()
+	v.weapons()
+	v.echoes()
+	v.sonatas()
+	v.stats()
+	v.substats()
+	v.codes()
+	v.materials()
+	v.costs()
+	return v.issues
+}
+
+func (v *validator) report(file, path, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{File: file, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) file(name string) string {
+	return filepath.Join(v.dir, name)
+}
+
+// duplicates reports every name that was already used by an earlier entry.
+func (v *validator) duplicates(file string, names []string, path func(int) string) {
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		slug := store.Slug(name)
+		if first, ok := seen[slug]; ok {
+			v.report(file, path(i), "duplicate name %q, first used at %s", displayName(name), path(first))
+			continue
+		}
+		seen[slug] = i
+	}
+}
+
+func displayName(name string) string {
+	return strings.ReplaceAll(name, "%20", " ")
+}
+
+func (v *validator) characters() {
+	seen := make(map[string]string)
+	for _, character := range v.s.Characters() {
+		file := character.Source
+		slug := store.Slug(character.Name)
+		if first, ok := seen[slug]; ok {
+			v.report(file, "$.name", "duplicate name %q, first used in %s", displayName(character.Name), first)
+		} else {
+			seen[slug] = file
+		}
+
+		if character.Attribute == "" {
+			v.report(file, "$.attribute", "missing attribute")
+		} else if _, ok := v.s.Attribute(character.Attribute); !ok && store.Slug(character.Attribute) != store.Slug(multiAttribute) {
+			v.report(file, "$.attribute", "unknown attribute %q", character.Attribute)
+		}
+
+		if character.Weapon == "" {
+			v.report(file, "$.weapon", "missing weapon")
+		} else if _, ok := v.s.Weapons(character.Weapon); !ok {
+			v.report(file, "$.weapon", "unknown weapon type %q", character.Weapon)
+		}
+
+		if character.Rarity < 4 || character.Rarity > 5 {
+			v.report(file, "$.rarity", "rarity %d out of range 4-5", character.Rarity)
+		}
+
+		for i, phase := range character.Ascension {
+			v.materialCosts(file, fmt.Sprintf("$.ascension[%d]", i), phase.Materials)
+		}
+		for i, cost := range character.Forte {
+			v.materialCosts(file, fmt.Sprintf("$.forte[%d]", i), cost.Materials)
+		}
+	}
+}
+
+func (v *validator) attributes() {
+	file := v.file(store.AttributesFile)
+	attributes := v.s.Attributes()
+
+	names := make([]string, len(attributes))
+	for i, attribute := range attributes {
+		names[i] = attribute.Name
+	}
+	v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$[%d].name", i) })
+
+	for i, attribute := range attributes {
+		for j, character := range attribute.Characters {
+			if _, ok := v.s.Character(character.Name); !ok {
+				v.report(file, fmt.Sprintf("$[%d].characters[%d].name", i, j), "unknown character %q", character.Name)
+			}
+		}
+	}
+}
+
+func (v *validator) weapons() {
+	file := v.file(store.WeaponsFile)
+
+	types := slices.Clone(v.s.WeaponTypes())
+	slices.Sort(types)
+
+	for _, weaponType := range types {
+		weapons, _ := v.s.Weapons(weaponType)
+
+		names := make([]string, len(weapons))
+		for i, weapon := range weapons {
+			names[i] = weapon.Name
+		}
+		v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$.%s[%d].name", weaponType, i) })
+
+		for i, weapon := range weapons {
+			path := fmt.Sprintf("$.%s[%d]", weaponType, i)
+			if store.Slug(weapon.Type) != store.Slug(weaponType) {
+				v.report(file, path+".type", "type %q does not match its group %q", weapon.Type, weaponType)
+			}
+			if weapon.Rarity < 1 || weapon.Rarity > 5 {
+				v.report(file, path+".rarity", "rarity %d out of range 1-5", weapon.Rarity)
+			}
+			if unit := weapon.Stats.Substat.Unit; unit != "" && unit != "%" {
+				v.report(file, path+".stats.substat.unit", "unit %q is not %% or empty", unit)
+			}
+			for j, point := range weapon.Stats.Levels {
+				pointPath := fmt.Sprintf("%s.stats.levels[%d]", path, j)
+				if point.Level < 1 || point.Level > models.MaxCharacterLevel {
+					v.report(file, pointPath+".level", "level %d out of range 1-%d", point.Level, models.MaxCharacterLevel)
+				}
+				if point.Ascension < 0 || point.Ascension > models.MaxAscension {
+					v.report(file, pointPath+".ascension", "ascension %d out of range 0-%d", point.Ascension, models.MaxAscension)
+				}
+				if point.ATK <= 0 {
+					v.report(file, pointPath+".atk", "atk must be positive")
+				}
+			}
+			for j, phase := range weapon.Ascension {
+				v.materialCosts(file, fmt.Sprintf("%s.ascension[%d]", path, j), phase.Materials)
+			}
+			v.placeholders(file, path+".skill", weapon.Skill.Description, len(weapon.Skill.Ranks))
+			for j, rank := range weapon.Skill.Ranks {
+				if len(rank) != calc.MaxRefinement {
+					v.report(file, fmt.Sprintf("%s.skill.ranks[%d]", path, j), "has %d refinement values, expected %d", len(rank), calc.MaxRefinement)
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) echoes() {
+	file := v.file(store.EchoesFile)
+	echoes := v.s.Echoes()
+
+	names := make([]string, len(echoes))
+	for i, echo := range echoes {
+		names[i] = echo.Name
+	}
+	v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$[%d].name", i) })
+
+	for i, echo := range echoes {
+		path := fmt.Sprintf("$[%d]", i)
+
+		if echo.Cost != 1 && echo.Cost != 3 && echo.Cost != 4 {
+			v.report(file, path+".cost", "cost %d is not 1, 3 or 4", echo.Cost)
+		}
+
+		for j, sonata := range echo.SonataEffects {
+			if _, ok := v.s.Sonata(sonata); !ok {
+				v.report(file, fmt.Sprintf("%s.sonataEffects[%d]", path, j), "unknown sonata %q", sonata)
+			}
+		}
+
+		v.echoRanks(file, path, echo)
+	}
+}
+
+// echoRanks checks that every {n} placeholder in the description has a rank
+// value and every rank value is referenced, and that all ranks carry the same
+// number of values.
+func (v *validator) echoRanks(file, path string, echo models.Echo) {
+	if len(echo.Ranks) == 0 {
+		v.placeholders(file, path, echo.Description, 0)
+		return
+	}
+
+	params := len(echo.Ranks[0].Values)
+	v.placeholders(file, path, echo.Description, params)
+
+	for j, rank := range echo.Ranks[1:] {
+		if len(rank.Values) != params {
+			v.report(file, fmt.Sprintf("%s.ranks", path), "rank %d has %d values, rank %d has %d", rank.Rank, len(rank.Values), echo.Ranks[0].Rank, params)
+		}
+		if rank.Rank <= echo.Ranks[j].Rank {
+			v.report(file, fmt.Sprintf("%s.ranks", path), "rank %d is listed after rank %d", rank.Rank, echo.Ranks[j].Rank)
+		}
+	}
+}
+
+// placeholders checks that the {n} placeholders of description and the params
+// rank parameters at path refer to each other.
+func (v *validator) placeholders(file, path, description string, params int) {
+	used := make(map[int]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(description, -1) {
+		n, _ := strconv.Atoi(match[1])
+		if used[n] {
+			continue
+		}
+		used[n] = true
+		if n >= params {
+			v.report(file, path+".description", "placeholder {%d} has no matching rank", n)
+		}
+	}
+	for n := 0; n < params; n++ {
+		if !used[n] {
+			v.report(file, path+".ranks", "rank parameter %d is not used by a {%d} placeholder", n, n)
+		}
+	}
+}
+
+func (v *validator) sonatas() {
+	file := v.file(store.SonatasFile)
+	sonatas := v.s.Sonatas()
+
+	names := make([]string, len(sonatas))
+	for i, sonata := range sonatas {
+		names[i] = sonata.Name
+	}
+	v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$[%d].name", i) })
+}
+
+func (v *validator) stats() {
+	file := v.file(store.StatsFile)
+	stats := v.s.Stats()
+
+	names := make([]string, len(stats))
+	for i, stat := range stats {
+		names[i] = stat.Name
+	}
+	v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$[%d].name", i) })
+
+	want := -1
+	check := func(path string, ranks []float64) {
+		if len(ranks) == 0 {
+			v.report(file, path, "no ranks")
+			return
+		}
+		if want < 0 {
+			want = len(ranks)
+		} else if len(ranks) != want {
+			v.report(file, path, "has %d ranks, expected %d", len(ranks), want)
+		}
+		for k := 1; k < len(ranks); k++ {
+			if ranks[k] < ranks[k-1] {
+				v.report(file, fmt.Sprintf("%s[%d]", path, k), "rank value %g is lower than the previous rank", ranks[k])
+			}
+		}
+	}
+
+	for i, stat := range stats {
+		for j, primary := range stat.Primary {
+			check(fmt.Sprintf("$[%d].primary[%d].ranks", i, j), primary.Ranks)
+		}
+		for j, secondary := range stat.Secondary {
+			check(fmt.Sprintf("$[%d].secondary[%d].ranks", i, j), secondary.Ranks)
+		}
+	}
+}
+
+func (v *validator) substats() {
+	file := v.file(store.SubstatsFile)
+	substats := v.s.Substats()
+
+	names := make([]string, len(substats))
+	for i, substat := range substats {
+		names[i] = substat.Name
+	}
+	v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$[%d].name", i) })
+
+	for i, substat := range substats {
+		if substat.Min > substat.Max {
+			v.report(file, fmt.Sprintf("$[%d]", i), "min %g is greater than max %g", substat.Min, substat.Max)
+		}
+	}
+}
+
+func (v *validator) codes() {
+	file := v.file(store.CodesFile)
+	codes := v.s.Codes()
+
+	names := make([]string, len(codes))
+	for i, code := range codes {
+		names[i] = code.Name
+	}
+	v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$[%d].name", i) })
+
+	for i, code := range codes {
+		if code.Added == nil {
+			v.report(file, fmt.Sprintf("$[%d].added", i), "needs the date it was added, the feeds leave it out otherwise")
+		}
+		if code.Added != nil && code.Expires != nil && code.Expires.End().Before(code.Added.Time) {
+			v.report(file, fmt.Sprintf("$[%d].expires", i), "expires before it was added")
+		}
+		for j, reward := range code.Rewards {
+			if reward.Item == "" || reward.Quantity <= 0 {
+				v.report(file, fmt.Sprintf("$[%d].rewards[%d]", i, j), "needs an item and a positive quantity")
+			}
+		}
+	}
+}
+
+func (v *validator) materials() {
+	file := v.file(store.MaterialsFile)
+	materials := v.s.Materials()
+
+	names := make([]string, len(materials))
+	for i, material := range materials {
+		names[i] = material.Name
+	}
+	v.duplicates(file, names, func(i int) string { return fmt.Sprintf("$[%d].name", i) })
+
+	for i, material := range materials {
+		if material.Rarity < 1 || material.Rarity > 5 {
+			v.report(file, fmt.Sprintf("$[%d].rarity", i), "rarity %d out of range 1-5", material.Rarity)
+		}
+	}
+}
+
+// materialCosts reports materials of an upgrade that are not in materials.json,
+// and shell credits listed among them instead of in the upgrade's credits,
+// where the planner would not count them as credits.
+func (v *validator) materialCosts(file, path string, costs []models.MaterialCost) {
+	for i, cost := range costs {
+		if store.Slug(cost.Name) == store.Slug(models.ShellCredit) {
+			v.report(file, fmt.Sprintf("%s.materials[%d].name", path, i), "%s belong in credits", models.ShellCredit)
+			continue
+		}
+		if _, ok := v.s.Material(cost.Name); !ok {
+			v.report(file, fmt.Sprintf("%s.materials[%d].name", path, i), "unknown material %q", cost.Name)
+		}
+	}
+}
+
+func (v *validator) costs() {
+	file := v.file(store.CostsFile)
+	costs := v.s.Costs()
+
+	for _, levels := range []struct {
+		path  string
+		costs models.LevelCosts
+	}{{"$.character", costs.Character}, {"$.weapon", costs.Weapon}} {
+		if len(levels.costs.EXP) > models.MaxCharacterLevel-1 {
+			v.report(file, levels.path+".exp", "has %d level ups, expected at most %d", len(levels.costs.EXP), models.MaxCharacterLevel-1)
+		}
+		if len(levels.costs.Credits) != len(levels.costs.EXP) {
+			v.report(file, levels.path+".credits", "has %d level ups, exp has %d", len(levels.costs.Credits), len(levels.costs.EXP))
+		}
+	}
+}
+
@@ -0,0 +1,126 @@
+package validation
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"api/models"
+	"api/store"
+)
+
+func decode[T any](t *testing.T, text string) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		t.Fatalf("Unmarshal(%s) = %v", text, err)
+	}
+	return v
+}
+
+// fixture returns a small dataset without issues.
+func fixture(t *testing.T) store.Data {
+	return store.Data{
+		Characters: []models.Character{
+			{Name: "Jiyan", Attribute: "Aero", Weapon: "Broadblade", Rarity: 5, Source: "data/characters/jiyan.json"},
+			{Name: "Rover", Attribute: "Multi", Weapon: "Sword", Rarity: 5, Source: "data/characters/rover.json"},
+		},
+		Attributes: []models.Attribute{{Name: "Aero", Characters: []models.Character{{Name: "Jiyan"}}}},
+		Weapons: map[string][]models.Weapon{
+			"Broadblade": {decode[models.Weapon](t, `{"name": "Verdant Summit", "type": "Broadblade", "rarity": 5,
+				"skill": {"description": "ATK +{0}", "ranks": [["12%", "15%", "18%", "21%", "24%"]]}}`)},
+			"Sword": {{Name: "Emerald of Genesis", Type: "Sword", Rarity: 5}},
+		},
+		Echoes: []models.Echo{
+			{Name: "Dreamless", Cost: 4, SonataEffects: []string{"Sun-sinking Eclipse"}, Description: "Deals {0}% DMG",
+				Ranks: models.EchoRanks{{Rank: 2, Values: []float64{100}}, {Rank: 3, Values: []float64{120}}}},
+		},
+		Sonatas: []models.Sonata{{Name: "Sun-sinking Eclipse"}},
+		Stats: decode[[]models.Stat](t, `[
+			{"cost": 4, "name": "4 cost", "primary": [{"name": "Crit Rate", "ranks": [4.4, 22]}], "secondary": [{"name": "ATK", "ranks": [30, 150]}]}
+		]`),
+		Substats: []models.Substat{{Name: "ATK", Min: 30, Max: 60}},
+	}
+}
+
+func TestValidateFixture(t *testing.T) {
+	// The Rover's Multi attribute is not an issue.
+	if issues := Validate(store.NewMemory(fixture(t)), "data"); len(issues) > 0 {
+		t.Errorf("Validate() = %v, want no issues", issues)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*store.Data)
+		file   string
+		path   string
+		text   string
+	}{
+		{
+			"dangling attribute", func(d *store.Data) { d.Characters[0].Attribute = "Wind" },
+			"data/characters/jiyan.json", "$.attribute", `unknown attribute "Wind"`,
+		},
+		{
+			"dangling weapon type", func(d *store.Data) { d.Characters[0].Weapon = "Lance" },
+			"data/characters/jiyan.json", "$.weapon", `unknown weapon type "Lance"`,
+		},
+		{
+			"dangling character", func(d *store.Data) { d.Attributes[0].Characters[0].Name = "Jiyam" },
+			filepath.Join("data", store.AttributesFile), "$[0].characters[0].name", `unknown character "Jiyam"`,
+		},
+		{
+			"dangling sonata", func(d *store.Data) { d.Echoes[0].SonataEffects[0] = "Moonlit Clouds" },
+			filepath.Join("data", store.EchoesFile), "$[0].sonataEffects[0]", `unknown sonata "Moonlit Clouds"`,
+		},
+		{
+			"duplicate name", func(d *store.Data) { d.Substats = append(d.Substats, models.Substat{Name: "atk", Min: 1, Max: 2}) },
+			filepath.Join("data", store.SubstatsFile), "$[1].name", `duplicate name "atk", first used at $[0].name`,
+		},
+		{
+			"character rarity", func(d *store.Data) { d.Characters[0].Rarity = 3 },
+			"data/characters/jiyan.json", "$.rarity", "rarity 3 out of range 4-5",
+		},
+		{
+			"weapon rarity", func(d *store.Data) { d.Weapons["Sword"][0].Rarity = 6 },
+			filepath.Join("data", store.WeaponsFile), "$.Sword[0].rarity", "rarity 6 out of range 1-5",
+		},
+		{
+			"placeholder without rank", func(d *store.Data) { d.Echoes[0].Description = "Deals {0}% and {1}% DMG" },
+			filepath.Join("data", store.EchoesFile), "$[0].description", "placeholder {1} has no matching rank",
+		},
+		{
+			"rank without placeholder", func(d *store.Data) { d.Weapons["Broadblade"][0].Skill.Description = "ATK up" },
+			filepath.Join("data", store.WeaponsFile), "$.Broadblade[0].skill.ranks", "rank parameter 0 is not used by a {0} placeholder",
+		},
+		{
+			"missing refinement", func(d *store.Data) {
+				d.Weapons["Broadblade"][0].Skill.Ranks[0] = d.Weapons["Broadblade"][0].Skill.Ranks[0][:4]
+			},
+			filepath.Join("data", store.WeaponsFile), "$.Broadblade[0].skill.ranks[0]", "has 4 refinement values, expected 5",
+		},
+		{
+			"stat ranks length", func(d *store.Data) { d.Stats[0].Secondary[0].Ranks = []float64{30, 90, 150} },
+			filepath.Join("data", store.StatsFile), "$[0].secondary[0].ranks", "has 3 ranks, expected 2",
+		},
+		{
+			"echo rank length", func(d *store.Data) { d.Echoes[0].Ranks[1].Values = []float64{120, 5} },
+			filepath.Join("data", store.EchoesFile), "$[0].ranks", "rank 3 has 2 values, rank 2 has 1",
+		},
+	}
+	for _, tt := range tests {
+		data := fixture(t)
+		tt.change(&data)
+		issues := Validate(store.NewMemory(data), "data")
+		if len(issues) != 1 {
+			t.Errorf("%s: Validate() = %v, want one issue", tt.name, issues)
+			continue
+		}
+		issue := issues[0]
+		if issue.File != tt.file || issue.Path != tt.path || !strings.Contains(issue.Message, tt.text) {
+			t.Errorf("%s: Validate() = %v, want %s: %s: %s", tt.name, issue, tt.file, tt.path, tt.text)
+		}
+	}
+}